@@ -0,0 +1,148 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	etcd "go.etcd.io/etcd/client/v3"
+)
+
+// sharedClients holds the etcd clients of the provider instances of this
+// process, so identical provider blocks share one gRPC connection.
+var sharedClients = newClientCache()
+
+// clientCacheKey is every provider setting the connection of a client
+// depends on.
+type clientCacheKey struct {
+	endpoints           string
+	username            string
+	password            string
+	certFile            string
+	keyFile             string
+	caFile              string
+	insecureSkipVerify  bool
+	tlsServerName       string
+	dialTimeout         time.Duration
+	block               bool
+	autoSyncInterval    time.Duration
+	keepAliveTime       time.Duration
+	keepAliveTimeout    time.Duration
+	permitWithoutStream bool
+	maxCallSendMsgSize  int
+	maxCallRecvMsgSize  int
+	balancer            string
+	namespace           string
+	maxRetries          int
+	proxyURL            string
+	waitForQuorum       bool
+	waitTimeout         string
+	debugGRPC           bool
+	debugGRPCPayloads   bool
+}
+
+func newClientCacheKey(d *schema.ResourceData, config etcd.Config) clientCacheKey {
+	return clientCacheKey{
+		endpoints:           strings.Join(config.Endpoints, ","),
+		username:            config.Username,
+		password:            config.Password,
+		certFile:            d.Get("cert_file").(string),
+		keyFile:             d.Get("key_file").(string),
+		caFile:              d.Get("ca_file").(string),
+		insecureSkipVerify:  d.Get("insecure_skip_verify").(bool),
+		tlsServerName:       d.Get("tls_server_name").(string),
+		dialTimeout:         config.DialTimeout,
+		block:               d.Get("block").(bool),
+		autoSyncInterval:    config.AutoSyncInterval,
+		keepAliveTime:       config.DialKeepAliveTime,
+		keepAliveTimeout:    config.DialKeepAliveTimeout,
+		permitWithoutStream: config.PermitWithoutStream,
+		maxCallSendMsgSize:  config.MaxCallSendMsgSize,
+		maxCallRecvMsgSize:  config.MaxCallRecvMsgSize,
+		balancer:            d.Get("balancer").(string),
+		namespace:           d.Get("namespace").(string),
+		maxRetries:          d.Get("max_retries").(int),
+		proxyURL:            d.Get("proxy_url").(string),
+		waitForQuorum:       d.Get("wait_for_quorum").(bool),
+		waitTimeout:         d.Get("wait_timeout").(string),
+		debugGRPC:           d.Get("debug_grpc").(bool),
+		debugGRPCPayloads:   d.Get("debug_grpc_payloads").(bool),
+	}
+}
+
+type cachedClient struct {
+	cli  *etcd.Client
+	err  error
+	refs int
+
+	// dialed is closed once cli or err is set.
+	dialed chan struct{}
+}
+
+type clientCache struct {
+	mu      sync.Mutex
+	clients map[clientCacheKey]*cachedClient
+}
+
+func newClientCache() *clientCache {
+	return &clientCache{clients: map[clientCacheKey]*cachedClient{}}
+}
+
+// acquire returns the client for key, dialing it with dial unless another
+// holder already did, and a func releasing it. The client is closed when its
+// last holder releases it. Clients of other keys are dialed concurrently.
+func (c *clientCache) acquire(key clientCacheKey, dial func() (*etcd.Client, error)) (*etcd.Client, func(), error) {
+	c.mu.Lock()
+	cached, ok := c.clients[key]
+	if !ok {
+		cached = &cachedClient{dialed: make(chan struct{})}
+		c.clients[key] = cached
+	}
+	cached.refs++
+	c.mu.Unlock()
+
+	if !ok {
+		cached.cli, cached.err = dial()
+		close(cached.dialed)
+	}
+	<-cached.dialed
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { c.release(key, cached) })
+	}
+	if cached.err != nil {
+		release()
+		return nil, nil, cached.err
+	}
+	return cached.cli, release, nil
+}
+
+func (c *clientCache) release(key clientCacheKey, cached *cachedClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached.refs--
+	if cached.refs > 0 {
+		return
+	}
+	if c.clients[key] == cached {
+		delete(c.clients, key)
+	}
+	if cached.cli != nil {
+		cached.cli.Close()
+	}
+}
+
+// releaseOnStop calls release once the provider instance is stopped, to
+// close its clients.
+func releaseOnStop(ctx context.Context, release func()) {
+	if stopCtx, ok := schema.StopContext(ctx); ok {
+		go func() {
+			<-stopCtx.Done()
+			release()
+		}()
+	}
+}
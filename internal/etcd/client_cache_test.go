@@ -0,0 +1,122 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	etcd "go.etcd.io/etcd/client/v3"
+)
+
+func TestClientCacheConcurrentAcquire(test *testing.T) {
+	cache := newClientCache()
+	key := clientCacheKey{endpoints: "127.0.0.1:0"}
+	var dials int32
+	dial := func() (*etcd.Client, error) {
+		atomic.AddInt32(&dials, 1)
+		return etcd.New(etcd.Config{Endpoints: []string{"127.0.0.1:0"}})
+	}
+
+	const holders = 32
+	clients := make([]*etcd.Client, holders)
+	releases := make([]func(), holders)
+	var wg sync.WaitGroup
+	for i := 0; i < holders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cli, release, err := cache.acquire(key, dial)
+			if err != nil {
+				test.Errorf("err: %s", err)
+				return
+			}
+			clients[i], releases[i] = cli, release
+		}(i)
+	}
+	wg.Wait()
+	if test.Failed() {
+		return
+	}
+
+	if dials != 1 {
+		test.Fatalf("expected a single dial, got %d", dials)
+	}
+	for _, cli := range clients {
+		if cli != clients[0] {
+			test.Fatalf("expected every holder to share the client")
+		}
+	}
+
+	for i := 0; i < holders-1; i++ {
+		wg.Add(1)
+		go func(release func()) {
+			defer wg.Done()
+			release()
+			release()
+		}(releases[i])
+	}
+	wg.Wait()
+	if clients[0].Ctx().Err() != nil {
+		test.Fatalf("expected the client to stay open while it is held")
+	}
+
+	releases[holders-1]()
+	if clients[0].Ctx().Err() == nil {
+		test.Fatalf("expected the client to be closed by the last release")
+	}
+	if len(cache.clients) != 0 {
+		test.Fatalf("expected the client to be removed from the cache, got %v", cache.clients)
+	}
+}
+
+func TestClientCacheDialError(test *testing.T) {
+	cache := newClientCache()
+	key := clientCacheKey{endpoints: "127.0.0.1:0"}
+
+	if _, _, err := cache.acquire(key, func() (*etcd.Client, error) {
+		return nil, errors.New("unreachable")
+	}); err == nil {
+		test.Fatalf("expected the dial error")
+	}
+	if len(cache.clients) != 0 {
+		test.Fatalf("expected a failed dial not to be cached")
+	}
+}
+
+func TestConfigureSharesClient(test *testing.T) {
+	configureClient := func(endpoint string) *apiClient {
+		d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+			"endpoints":    []interface{}{endpoint},
+			"check_health": false,
+		})
+		meta, diags := configure(context.Background(), d)
+		if diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+		return meta.(*apiClient)
+	}
+
+	first := configureClient("127.0.0.1:1")
+	second := configureClient("127.0.0.1:1")
+	other := configureClient("127.0.0.1:2")
+	defer other.Close()
+
+	if first.Client != second.Client {
+		test.Fatalf("expected identical provider configurations to share a client")
+	}
+	if other.Client == first.Client {
+		test.Fatalf("expected other endpoints to use another client")
+	}
+
+	first.Close()
+	if second.Ctx().Err() != nil {
+		test.Fatalf("expected the client to stay open for the second provider")
+	}
+	second.Close()
+	if second.Ctx().Err() == nil {
+		test.Fatalf("expected the client to be closed once released by both providers")
+	}
+}
@@ -0,0 +1,79 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+)
+
+// clusterIDGuard remembers the ID of the cluster that answered the first
+// request and rejects responses from any other cluster, which happens when
+// the endpoints are repointed mid-run, e.g. after a disaster-recovery restore.
+type clusterIDGuard struct {
+	mu sync.Mutex
+	id uint64
+}
+
+// clusterReadMethods are the methods that do not change the cluster, checked
+// once they are answered. Any other method is only sent after a status
+// request on the same connection confirmed the cluster, so a write never
+// lands on a different cluster.
+var clusterReadMethods = map[string]bool{
+	"/etcdserverpb.KV/Range":              true,
+	"/etcdserverpb.Lease/LeaseTimeToLive": true,
+	"/etcdserverpb.Lease/LeaseLeases":     true,
+	"/etcdserverpb.Cluster/MemberList":    true,
+	"/etcdserverpb.Maintenance/Status":    true,
+	"/etcdserverpb.Maintenance/Hash":      true,
+	"/etcdserverpb.Maintenance/HashKV":    true,
+	"/etcdserverpb.Auth/Authenticate":     true,
+	"/etcdserverpb.Auth/AuthStatus":       true,
+	"/etcdserverpb.Auth/UserGet":          true,
+	"/etcdserverpb.Auth/UserList":         true,
+	"/etcdserverpb.Auth/RoleGet":          true,
+	"/etcdserverpb.Auth/RoleList":         true,
+}
+
+func (g *clusterIDGuard) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !clusterReadMethods[method] {
+		// The status response goes through this interceptor as well, which
+		// checks its cluster ID.
+		if err := cc.Invoke(ctx, "/etcdserverpb.Maintenance/Status", &pb.StatusRequest{}, &pb.StatusResponse{}); err != nil {
+			return err
+		}
+	}
+	if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+		return err
+	}
+	response, ok := reply.(interface{ GetHeader() *pb.ResponseHeader })
+	if !ok {
+		return nil
+	}
+	return g.check(response.GetHeader().GetClusterId())
+}
+
+func (g *clusterIDGuard) check(id uint64) error {
+	if id == 0 {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.id == 0 {
+		g.id = id
+		return nil
+	}
+	if id != g.id {
+		return fmt.Errorf("etcd cluster ID changed from %x to %x during the run, the endpoints now point at a different cluster; refusing to continue", g.id, id)
+	}
+	return nil
+}
+
+// clusterID returns the recorded cluster ID, or 0 before the first response.
+func (g *clusterIDGuard) clusterID() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.id
+}
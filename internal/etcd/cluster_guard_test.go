@@ -0,0 +1,149 @@
+package etcd
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+)
+
+// clusterServer is a KV server answering with the ID of the cluster it
+// pretends to be, counting the puts it applied.
+type clusterServer struct {
+	pb.UnimplementedKVServer
+	pb.UnimplementedMaintenanceServer
+
+	mu   sync.Mutex
+	id   uint64
+	puts int
+}
+
+func (s *clusterServer) header() *pb.ResponseHeader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &pb.ResponseHeader{ClusterId: s.id}
+}
+
+func (s *clusterServer) setID(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id = id
+}
+
+func (s *clusterServer) applied() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.puts
+}
+
+func (s *clusterServer) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
+	s.mu.Lock()
+	s.puts++
+	s.mu.Unlock()
+	return &pb.PutResponse{Header: s.header()}, nil
+}
+
+// Txn applies the success branch without evaluating the compares.
+func (s *clusterServer) Txn(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	response := &pb.TxnResponse{Header: s.header(), Succeeded: true}
+	for _, op := range req.Success {
+		switch op.Request.(type) {
+		case *pb.RequestOp_RequestPut:
+			put, _ := s.Put(ctx, op.GetRequestPut())
+			response.Responses = append(response.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponsePut{ResponsePut: put}})
+		case *pb.RequestOp_RequestRange:
+			get, _ := s.Range(ctx, op.GetRequestRange())
+			response.Responses = append(response.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponseRange{ResponseRange: get}})
+		}
+	}
+	return response, nil
+}
+
+func (s *clusterServer) Status(ctx context.Context, req *pb.StatusRequest) (*pb.StatusResponse, error) {
+	return &pb.StatusResponse{Header: s.header()}, nil
+}
+
+func (s *clusterServer) Range(ctx context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	return &pb.RangeResponse{Header: s.header()}, nil
+}
+
+// serveCluster starts a clusterServer for the cluster id.
+func serveCluster(test *testing.T, id uint64) (string, *clusterServer) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	cluster := &clusterServer{id: id}
+	server := grpc.NewServer()
+	pb.RegisterKVServer(server, cluster)
+	pb.RegisterMaintenanceServer(server, cluster)
+	go server.Serve(listener)
+	test.Cleanup(server.Stop)
+	return listener.Addr().String(), cluster
+}
+
+func TestClusterIDGuardTripsOnClusterChange(test *testing.T) {
+	ctx := context.Background()
+	guard := &clusterIDGuard{}
+
+	clusterID := uint64(0xa1)
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		reply.(*pb.RangeResponse).Header = &pb.ResponseHeader{ClusterId: clusterID}
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := guard.unaryInterceptor(ctx, "/etcdserverpb.KV/Range", nil, &pb.RangeResponse{}, nil, invoker); err != nil {
+			test.Fatalf("err: %s", err)
+		}
+	}
+	if got := guard.clusterID(); got != 0xa1 {
+		test.Fatalf("expected cluster ID a1 to be recorded, got %x", got)
+	}
+
+	// The endpoints now answer from a restored cluster.
+	clusterID = 0xb2
+	err := guard.unaryInterceptor(ctx, "/etcdserverpb.KV/Range", nil, &pb.RangeResponse{}, nil, invoker)
+	if err == nil || !strings.Contains(err.Error(), "cluster ID changed from a1 to b2") {
+		test.Fatalf("expected the guard to trip, got %v", err)
+	}
+	if got := guard.clusterID(); got != 0xa1 {
+		test.Fatalf("expected the original cluster ID to be kept, got %x", got)
+	}
+}
+
+func TestClusterIDGuardBlocksWritesToAnotherCluster(test *testing.T) {
+	ctx := context.Background()
+	endpoint, cluster := serveCluster(test, 0xa1)
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":         []interface{}{endpoint},
+		"check_health":      false,
+		"verify_cluster_id": true,
+	})
+	meta, diags := configure(ctx, d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	if _, err := client.Put(ctx, "/app/name", "passbase"); err != nil {
+		test.Fatalf("err: %s", err)
+	}
+
+	// The endpoints now answer from a restored cluster, the first write to
+	// it is refused before it is sent.
+	cluster.setID(0xb2)
+	if _, err := client.Put(ctx, "/app/name", "awesome"); err == nil || !strings.Contains(err.Error(), "cluster ID changed from a1 to b2") {
+		test.Fatalf("expected the guard to trip, got %v", err)
+	}
+	if applied := cluster.applied(); applied != 1 {
+		test.Fatalf("expected only the write to the original cluster to be applied, got %d", applied)
+	}
+}
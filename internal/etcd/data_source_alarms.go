@@ -0,0 +1,60 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func AlarmsDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the active alarms of the cluster.",
+		ReadContext: alarmsDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"alarms": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"member_id": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ID of the member that raised the alarm in hexadecimal, as printed by etcdctl.",
+						},
+						"alarm_type": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Type of the alarm, `NOSPACE` or `CORRUPT`.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func alarmsDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	response, err := client.AlarmList(ctx)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to list the cluster alarms: %w", err))
+	}
+
+	alarms := []interface{}{}
+	for _, alarm := range response.Alarms {
+		alarms = append(alarms, map[string]interface{}{
+			"member_id":  strconv.FormatUint(alarm.MemberID, 16),
+			"alarm_type": alarm.Alarm.String(),
+		})
+	}
+
+	if err := d.Set("alarms", alarms); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("alarms")
+	return nil
+}
@@ -0,0 +1,36 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestAlarmsDataSourceRead(test *testing.T) {
+	maintenance := newFakeMaintenance()
+	maintenance.alarms = []*pb.AlarmMember{
+		{MemberID: 0x1a, Alarm: pb.AlarmType_NOSPACE},
+		{MemberID: 0x2b, Alarm: pb.AlarmType_CORRUPT},
+	}
+	client := newFakeClient(newFakeKV())
+	client.Maintenance = maintenance
+
+	d := schema.TestResourceDataRaw(test, AlarmsDataSource().Schema, map[string]interface{}{})
+	if diags := alarmsDataSourceRead(context.Background(), d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	for attribute, expected := range map[string]interface{}{
+		"alarms.#":            2,
+		"alarms.0.member_id":  "1a",
+		"alarms.0.alarm_type": "NOSPACE",
+		"alarms.1.member_id":  "2b",
+		"alarms.1.alarm_type": "CORRUPT",
+	} {
+		if got := d.Get(attribute); got != expected {
+			test.Fatalf("%s: expected %v, got %v", attribute, expected, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func AuthStatusDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reports whether authentication is enabled on the cluster.",
+		ReadContext: authStatusDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"auth_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Revision of the auth store, bumped by every user, role or permission change.",
+			},
+		},
+	}
+}
+
+func authStatusDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	status, err := client.AuthStatus(ctx)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if err := d.Set("enabled", status.Enabled); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("auth_revision", int(status.AuthRevision)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("auth_status")
+	return nil
+}
@@ -49,7 +49,7 @@ func clusterDataSourceRead(ctx context.Context, d *schema.ResourceData, meta int
 	client := meta.(*apiClient)
 	clusters, err := client.Cluster.MemberList(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	memberList := []interface{}{}
 	members := map[string]interface{}{}
@@ -0,0 +1,78 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func MembersDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the current members of the cluster.",
+		ReadContext: membersDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"member_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"members": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Member ID in hexadecimal, as printed by etcdctl.",
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"peer_urls": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"client_urls": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func membersDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	response, err := client.MemberList(ctx)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to list the cluster members: %w", err))
+	}
+
+	members := []interface{}{}
+	for _, member := range response.Members {
+		members = append(members, map[string]interface{}{
+			"id":          strconv.FormatUint(member.ID, 16),
+			"name":        member.Name,
+			"peer_urls":   member.PeerURLs,
+			"client_urls": member.ClientURLs,
+		})
+	}
+
+	if err := d.Set("members", members); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("member_count", len(members)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strconv.FormatUint(response.Header.GetClusterId(), 16))
+	return nil
+}
@@ -0,0 +1,51 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestMembersDataSourceRead(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.Cluster = &fakeCluster{members: []*pb.Member{
+		{ID: 0x8e9e05c52164694d, Name: "etcd-0", PeerURLs: []string{"http://10.0.0.1:2380"}, ClientURLs: []string{"http://10.0.0.1:2379"}},
+		{ID: 0x91bc3c398fb3c146, Name: "etcd-1", PeerURLs: []string{"http://10.0.0.2:2380"}, ClientURLs: []string{"http://10.0.0.2:2379"}},
+	}}
+
+	d := schema.TestResourceDataRaw(test, MembersDataSource().Schema, map[string]interface{}{})
+	if diags := membersDataSourceRead(context.Background(), d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	for attribute, expected := range map[string]interface{}{
+		"member_count":            2,
+		"members.0.id":            "8e9e05c52164694d",
+		"members.0.name":          "etcd-0",
+		"members.0.client_urls.0": "http://10.0.0.1:2379",
+		"members.1.name":          "etcd-1",
+		"members.1.peer_urls.0":   "http://10.0.0.2:2380",
+	} {
+		if got := d.Get(attribute); got != expected {
+			test.Fatalf("%s: expected %v, got %v", attribute, expected, got)
+		}
+	}
+}
+
+func TestMembersDataSourceUnreachable(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.Cluster = &fakeCluster{err: errors.New("context deadline exceeded")}
+
+	d := schema.TestResourceDataRaw(test, MembersDataSource().Schema, map[string]interface{}{})
+	diags := membersDataSourceRead(context.Background(), d, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "cluster members") {
+		test.Fatalf("expected a diagnostic, got %v", diags)
+	}
+	if d.Id() != "" {
+		test.Fatalf("expected no ID to be set")
+	}
+}
@@ -0,0 +1,143 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// defaultQuotaBackendBytes is the default --quota-backend-bytes of etcd.
+const defaultQuotaBackendBytes = 2 * 1024 * 1024 * 1024
+
+// statusSchema are the status attributes of a single endpoint.
+func statusSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"version": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "etcd version of the member.",
+		},
+		"db_size": &schema.Schema{
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Size in bytes of the backend database of the member.",
+		},
+		"db_size_in_use": &schema.Schema{
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Size in bytes of the backend database actually in use, the rest is reclaimed by a defragmentation. `0` when the member does not report it.",
+		},
+		"usage_ratio": &schema.Schema{
+			Type:        schema.TypeFloat,
+			Computed:    true,
+			Description: "Ratio of `db_size` to `quota_backend_bytes`, the member raises a NOSPACE alarm when it reaches 1.",
+		},
+		"leader": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Member ID of the leader in hexadecimal, as printed by etcdctl.",
+		},
+		"raft_index": &schema.Schema{
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"raft_term": &schema.Schema{
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+	}
+}
+
+func StatusDataSource() *schema.Resource {
+	s := statusSchema()
+	s["endpoint"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Endpoint to read the status of, the first configured endpoint when unset.",
+	}
+
+	s["quota_backend_bytes"] = &schema.Schema{
+		Type:         schema.TypeInt,
+		Optional:     true,
+		Default:      defaultQuotaBackendBytes,
+		ValidateFunc: validation.IntAtLeast(1),
+		Description:  "The `--quota-backend-bytes` of the members, which they do not report, to compute `usage_ratio` against.",
+	}
+
+	endpoint := statusSchema()
+	endpoint["endpoint"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Computed: true,
+	}
+	s["endpoints"] = &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "Status of every configured endpoint, or only of `endpoint` when it is set.",
+		Elem:        &schema.Resource{Schema: endpoint},
+	}
+
+	return &schema.Resource{
+		Description: "Reads the status of the cluster members: version, database size, leader and raft progress.",
+		ReadContext: statusDataSourceRead,
+		Schema:      s,
+	}
+}
+
+func statusDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	endpoints := client.endpoints
+	if endpoint := d.Get("endpoint").(string); endpoint != "" {
+		endpoints = []string{endpoint}
+	}
+	if len(endpoints) == 0 {
+		return diag.Errorf("no endpoint to read the status of")
+	}
+
+	quota := int64(d.Get("quota_backend_bytes").(int))
+	statuses := []interface{}{}
+	for _, endpoint := range endpoints {
+		status, err := client.Status(ctx, endpoint)
+		if err != nil {
+			return classifyEtcdError(fmt.Errorf("unable to read the status of endpoint %s: %w", endpoint, err))
+		}
+		// Members older than 3.4 do not report the size in use.
+		if status.DbSizeInUse == 0 {
+			logf(ctx, "DEBUG", "endpoint %s running etcd %s does not report db_size_in_use", endpoint, status.Version)
+		}
+		attributes := flattenStatus(status, quota)
+		attributes["endpoint"] = endpoint
+		statuses = append(statuses, attributes)
+	}
+
+	for name, value := range statuses[0].(map[string]interface{}) {
+		if name == "endpoint" {
+			continue
+		}
+		if err := d.Set(name, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if err := d.Set("endpoints", statuses); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(endpoints[0])
+	return nil
+}
+
+func flattenStatus(status *clientv3.StatusResponse, quota int64) map[string]interface{} {
+	return map[string]interface{}{
+		"version":        status.Version,
+		"db_size":        status.DbSize,
+		"db_size_in_use": status.DbSizeInUse,
+		"usage_ratio":    float64(status.DbSize) / float64(quota),
+		"leader":         strconv.FormatUint(status.Leader, 16),
+		"raft_index":     status.RaftIndex,
+		"raft_term":      status.RaftTerm,
+	}
+}
@@ -0,0 +1,94 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func newStatusTestClient() *apiClient {
+	maintenance := newFakeMaintenance()
+	maintenance.addEndpoint("10.0.0.1:2379", 1024)
+	maintenance.addEndpoint("10.0.0.2:2379", 2048)
+	maintenance.status["10.0.0.1:2379"].RaftIndex = 42
+	maintenance.status["10.0.0.1:2379"].RaftTerm = 3
+	maintenance.status["10.0.0.2:2379"].Leader = 26
+
+	client := newFakeClient(newFakeKV())
+	client.Maintenance = maintenance
+	client.endpoints = []string{"10.0.0.1:2379", "10.0.0.2:2379"}
+	return client
+}
+
+func TestStatusDataSourceReadAllEndpoints(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, StatusDataSource().Schema, map[string]interface{}{})
+	if diags := statusDataSourceRead(context.Background(), d, newStatusTestClient()); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	for attribute, expected := range map[string]interface{}{
+		"version":              "3.5.0",
+		"db_size":              1024,
+		"leader":               "1",
+		"raft_index":           42,
+		"raft_term":            3,
+		"endpoints.#":          2,
+		"endpoints.1.endpoint": "10.0.0.2:2379",
+		"endpoints.1.db_size":  2048,
+		"endpoints.1.leader":   "1a",
+	} {
+		if got := d.Get(attribute); got != expected {
+			test.Fatalf("%s: expected %v, got %v", attribute, expected, got)
+		}
+	}
+}
+
+func TestStatusDataSourceReadEndpoint(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, StatusDataSource().Schema, map[string]interface{}{
+		"endpoint": "10.0.0.2:2379",
+	})
+	if diags := statusDataSourceRead(context.Background(), d, newStatusTestClient()); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got := d.Get("db_size").(int); got != 2048 {
+		test.Fatalf("db_size: expected 2048, got %d", got)
+	}
+	if got := d.Get("endpoints.#").(int); got != 1 {
+		test.Fatalf("endpoints: expected only the requested endpoint, got %d", got)
+	}
+}
+
+func TestStatusDataSourceReadUnreachable(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, StatusDataSource().Schema, map[string]interface{}{
+		"endpoint": "10.0.0.3:2379",
+	})
+	diags := statusDataSourceRead(context.Background(), d, newStatusTestClient())
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "10.0.0.3:2379") {
+		test.Fatalf("expected an error naming the endpoint, got %v", diags)
+	}
+}
+
+func TestStatusDataSourceReadUsage(test *testing.T) {
+	client := newStatusTestClient()
+	client.Maintenance.(*fakeMaintenance).status["10.0.0.1:2379"].DbSizeInUse = 512
+
+	d := schema.TestResourceDataRaw(test, StatusDataSource().Schema, map[string]interface{}{
+		"quota_backend_bytes": 4096,
+	})
+	if diags := statusDataSourceRead(context.Background(), d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	for attribute, expected := range map[string]interface{}{
+		"db_size_in_use":             512,
+		"usage_ratio":                0.25,
+		"endpoints.1.usage_ratio":    0.5,
+		"endpoints.1.db_size_in_use": 0,
+	} {
+		if got := d.Get(attribute); got != expected {
+			test.Fatalf("%s: expected %v, got %v", attribute, expected, got)
+		}
+	}
+}
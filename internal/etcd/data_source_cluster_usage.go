@@ -0,0 +1,90 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func ClusterUsageDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reports the number of keys and the database size of the cluster. Counting scans the whole keyspace, so it has to be confirmed.",
+		ReadContext: clusterUsageDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"confirm": &schema.Schema{
+				Type:        schema.TypeBool,
+				Required:    true,
+				Description: "Must be `true` to acknowledge that reading scans every key of the cluster.",
+			},
+			"key_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"db_size": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Sum of the database sizes, in bytes, of all endpoints.",
+			},
+			"endpoints": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"endpoint": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"db_size": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func clusterUsageDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	if !d.Get("confirm").(bool) {
+		return diag.FromErr(errors.New("confirm must be true to scan every key of the cluster"))
+	}
+
+	// Count every key from the lowest possible key without returning values.
+	count, err := client.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithCountOnly())
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	var total int64
+	endpoints := []interface{}{}
+	for _, endpoint := range client.endpoints {
+		status, err := client.Status(ctx, endpoint)
+		if err != nil {
+			return diag.Errorf("unable to read the status of endpoint %s: %v", endpoint, err)
+		}
+		total += status.DbSize
+		endpoints = append(endpoints, map[string]interface{}{
+			"endpoint": endpoint,
+			"db_size":  status.DbSize,
+		})
+	}
+
+	if err := d.Set("key_count", count.Count); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("db_size", total); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("endpoints", endpoints); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("cluster_usage")
+	return nil
+}
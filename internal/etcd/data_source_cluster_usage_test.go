@@ -0,0 +1,49 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestClusterUsageDataSourceRead(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	for i := 0; i < 12; i++ {
+		kv.Put(ctx, fmt.Sprintf("/app/%d", i), "value")
+	}
+	maintenance := newFakeMaintenance()
+	maintenance.addEndpoint("10.0.0.1:2379", 1024)
+	maintenance.addEndpoint("10.0.0.2:2379", 2048)
+
+	client := newFakeClient(kv)
+	client.Maintenance = maintenance
+	client.endpoints = []string{"10.0.0.1:2379", "10.0.0.2:2379"}
+
+	d := schema.TestResourceDataRaw(test, ClusterUsageDataSource().Schema, map[string]interface{}{
+		"confirm": true,
+	})
+	if diags := clusterUsageDataSourceRead(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got := d.Get("key_count").(int); got != 12 {
+		test.Fatalf("key_count: expected 12, got %d", got)
+	}
+	if got := d.Get("db_size").(int); got != 3072 {
+		test.Fatalf("db_size: expected 3072, got %d", got)
+	}
+	if got := d.Get("endpoints.1.db_size").(int); got != 2048 {
+		test.Fatalf("endpoints.1.db_size: expected 2048, got %d", got)
+	}
+}
+
+func TestClusterUsageDataSourceRequiresConfirm(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, ClusterUsageDataSource().Schema, map[string]interface{}{
+		"confirm": false,
+	})
+	if diags := clusterUsageDataSourceRead(context.Background(), d, newFakeClient(newFakeKV())); !diags.HasError() {
+		test.Fatalf("expected an error without confirm")
+	}
+}
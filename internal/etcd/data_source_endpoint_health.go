@@ -0,0 +1,133 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func EndpointHealthDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Checks the health of every configured endpoint, like `etcdctl endpoint health`.",
+		ReadContext: endpointHealthDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "5s",
+				ValidateFunc: validateDuration,
+				Description:  "How long the check of a single endpoint may take before it is reported unhealthy, as a duration such as `5s`.",
+			},
+			"healthy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether every endpoint is healthy.",
+			},
+			"endpoints": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Health of every configured endpoint, in the order they are configured.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"endpoint": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"healthy": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"took": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "How long the check took, as a duration such as `2.5ms`.",
+						},
+						"error": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Why the endpoint is unhealthy, empty when it is healthy.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func endpointHealthDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	if len(client.endpoints) == 0 {
+		return diag.Errorf("no endpoint to check the health of")
+	}
+	timeout, err := time.ParseDuration(d.Get("timeout").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// An unreachable endpoint takes the whole timeout, the endpoints are
+	// checked concurrently so it does not delay the others.
+	results := make([]interface{}, len(client.endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range client.endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			results[i] = checkEndpoint(ctx, client, endpoint, timeout)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	healthy := true
+	for _, result := range results {
+		if !result.(map[string]interface{})["healthy"].(bool) {
+			healthy = false
+		}
+	}
+	logf(ctx, "DEBUG", "checked the health of %d endpoints, healthy: %v", len(results), healthy)
+
+	if err := d.Set("healthy", healthy); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("endpoints", results); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strings.Join(client.endpoints, ","))
+	return nil
+}
+
+// checkEndpoint runs the health check of etcdctl against endpoint: a
+// linearizable read of the key health, which needs a leader and a quorum. A
+// permission error still proves the member can serve requests.
+func checkEndpoint(ctx context.Context, client *apiClient, endpoint string, timeout time.Duration) map[string]interface{} {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The client is dialed for this check alone, so an endpoint that cannot
+	// be connected to only takes the timeout of its own check.
+	start := time.Now()
+	endpointClient, release, err := client.dialEndpoints(ctx, []string{endpoint})
+	if err == nil {
+		_, err = endpointClient.Get(ctx, "health")
+		release()
+	}
+	took := time.Since(start)
+
+	result := map[string]interface{}{
+		"endpoint": endpoint,
+		"healthy":  err == nil || err == rpctypes.ErrPermissionDenied,
+		"took":     took.String(),
+		"error":    "",
+	}
+	if !result["healthy"].(bool) {
+		result["error"] = err.Error()
+		logf(ctx, "DEBUG", "endpoint %s is unhealthy: %v", endpoint, err)
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc"
+)
+
+func TestEndpointHealthDataSourceRead(test *testing.T) {
+	healthy := serveKV(test)
+	// A member rejecting the read still serves requests.
+	denied := serveKV(test, grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return nil, rpctypes.ErrGRPCPermissionDenied
+	}))
+	unreachable := "127.0.0.1:1"
+
+	p := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":    []interface{}{healthy, unreachable, denied},
+		"check_health": false,
+	})
+	meta, diags := configure(context.Background(), p)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	d := schema.TestResourceDataRaw(test, EndpointHealthDataSource().Schema, map[string]interface{}{
+		"timeout": "300ms",
+	})
+	if diags := endpointHealthDataSourceRead(context.Background(), d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	if d.Get("healthy").(bool) {
+		test.Fatalf("expected the cluster to be reported unhealthy")
+	}
+	for i, expected := range []struct {
+		endpoint string
+		healthy  bool
+	}{
+		{healthy, true},
+		{unreachable, false},
+		{denied, true},
+	} {
+		result := d.Get("endpoints").([]interface{})[i].(map[string]interface{})
+		if result["endpoint"] != expected.endpoint || result["healthy"] != expected.healthy {
+			test.Fatalf("endpoints.%d: expected %s healthy=%v, got %v", i, expected.endpoint, expected.healthy, result)
+		}
+		if (result["error"] == "") != expected.healthy {
+			test.Fatalf("endpoints.%d: expected an error only when unhealthy, got %q", i, result["error"])
+		}
+		if result["took"] == "" {
+			test.Fatalf("endpoints.%d: expected the duration of the check", i)
+		}
+	}
+}
+
+func TestEndpointHealthDataSourceTimeout(test *testing.T) {
+	p := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":    []interface{}{serveKV(test), "127.0.0.1:1", "127.0.0.1:2"},
+		"check_health": false,
+		"block":        true,
+		"dial_timeout": "5s",
+	})
+	meta, diags := configure(context.Background(), p)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	// Each endpoint is dialed within its own check, bounded by timeout
+	// rather than dial_timeout, and concurrently with the other.
+	const timeout = 500 * time.Millisecond
+	d := schema.TestResourceDataRaw(test, EndpointHealthDataSource().Schema, map[string]interface{}{
+		"timeout": timeout.String(),
+	})
+	start := time.Now()
+	if diags := endpointHealthDataSourceRead(context.Background(), d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if took := time.Since(start); took >= 2*timeout {
+		test.Fatalf("expected the endpoints to be checked within one timeout of %s, took %s", timeout, took)
+	}
+	if d.Get("healthy").(bool) {
+		test.Fatalf("expected the unreachable endpoints to be reported unhealthy")
+	}
+	for i, expected := range []bool{true, false, false} {
+		if healthy := d.Get(fmt.Sprintf("endpoints.%d.healthy", i)).(bool); healthy != expected {
+			test.Fatalf("endpoints.%d: expected healthy=%v, got %v", i, expected, healthy)
+		}
+	}
+}
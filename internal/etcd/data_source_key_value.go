@@ -2,12 +2,18 @@ package etcd
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"unicode/utf8"
 	// "time"
 	// "strconv"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 func KeyValueDataSource() *schema.Resource {
@@ -19,9 +25,48 @@ func KeyValueDataSource() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+			"serializable": serializableSchema(),
+			"rev": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Read the key as of this cluster revision instead of the latest one.",
+			},
 			"value": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Value of the key, empty when it is not valid UTF-8.",
+			},
+			"value_base64": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Base64 encoded value of the key.",
+			},
+			"is_binary": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the value is not valid UTF-8 and only available as `value_base64`.",
+			},
+			"version": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of times the key was written since it was created.",
+			},
+			"create_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Cluster revision at which the key was created.",
+			},
+			"mod_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Cluster revision at which the key was last written.",
+			},
+			"lease": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the lease attached to the key, `0` when there is none.",
 			},
 
 			"id": &schema.Schema{
@@ -40,30 +85,108 @@ func keyValueDataSourceRead(ctx context.Context, d *schema.ResourceData, meta in
 		return diag.FromErr(errmsg)
 
 	}
-	value, err := client.Get(ctx, key)
+	opts := readConsistency(d)
+	rev := int64(d.Get("rev").(int))
+	if rev > 0 {
+		opts = append(opts, clientv3.WithRev(rev))
+	}
+	value, err := client.Get(ctx, key, opts...)
 	if err != nil {
-		return diag.FromErr(err)
+		return historicalReadDiagnostics(key, rev, err)
 	}
 
-	var keyValue string 
+	if len(value.Kvs) == 0 {
+		if rev > 0 {
+			return diag.Errorf("key %q does not exist at revision %d", key, rev)
+		}
+		return diag.Errorf("key %q does not exist", key)
+	}
+
+	kv := value.Kvs[0]
+	raw := kv.Value
 
-	if len(value.Kvs) > 0 {
-		keyValue = string(value.Kvs[0].Value)
+	isBinary := !utf8.Valid(raw)
 
-	} 
+	var keyValue string
+	if !isBinary {
+		keyValue = string(raw)
+	}
 
 	if err := d.Set("value", keyValue); err != nil {
 		return diag.FromErr(err)
 
 	}
 
+	if err := d.Set("value_base64", base64.StdEncoding.EncodeToString(raw)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("is_binary", isBinary); err != nil {
+		return diag.FromErr(err)
+	}
+
 	if err := d.Set("key", key); err != nil {
 		return diag.FromErr(err)
 
 	}
 
+	if err := d.Set("version", kv.Version); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("create_revision", kv.CreateRevision); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("mod_revision", kv.ModRevision); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("lease", formatLeaseID(clientv3.LeaseID(kv.Lease))); err != nil {
+		return diag.FromErr(err)
+	}
+
 	//d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
 	d.SetId(key)
 
 	return nil
 }
+
+// historicalReadDiagnostics describes why reading key at rev failed, since
+// old revisions are routinely dropped by compaction.
+func historicalReadDiagnostics(key string, rev int64, err error) diag.Diagnostics {
+	switch err = rpctypes.Error(err); {
+	case errors.Is(err, rpctypes.ErrCompacted):
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Revision was compacted",
+			Detail:   fmt.Sprintf("Key %q cannot be read at revision %d, the history up to that revision was compacted. Read a more recent revision, or restore a snapshot taken before the compaction.", key, rev),
+		}}
+	case errors.Is(err, rpctypes.ErrFutureRev):
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Revision is in the future",
+			Detail:   fmt.Sprintf("Key %q cannot be read at revision %d, the cluster has not reached that revision yet.", key, rev),
+		}}
+	}
+	return classifyEtcdError(err)
+}
+
+// serializableSchema is the serializable argument of the data sources.
+func serializableSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Read from the member the request is sent to without going through the leader, faster but possibly stale.",
+	}
+}
+
+// readConsistency returns the read options selected by serializable. Reads
+// are linearizable unless it is set.
+func readConsistency(d *schema.ResourceData) []clientv3.OpOption {
+	if d.Get("serializable").(bool) {
+		return []clientv3.OpOption{clientv3.WithSerializable()}
+	}
+	return nil
+}
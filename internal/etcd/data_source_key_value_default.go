@@ -0,0 +1,72 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func KvDefaultDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads a key, falling back to a default value when it does not exist.",
+		ReadContext: kvDefaultDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"key": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"default": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "Value returned when the key does not exist.",
+			},
+			"serializable": serializableSchema(),
+			"value": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Value of the key, or `default` when it does not exist.",
+			},
+			"found": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the key exists, to tell an empty value apart from a missing key.",
+			},
+			"id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func kvDefaultDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	key := d.Get("key").(string)
+	if key == "" {
+		return diag.Errorf("key is empty")
+	}
+
+	response, err := client.Get(ctx, key, readConsistency(d)...)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	// A key holding an empty value is still returned, only a missing key
+	// falls back to the default.
+	found := len(response.Kvs) > 0
+	value := d.Get("default").(string)
+	if found {
+		value = string(response.Kvs[0].Value)
+	}
+
+	if err := d.Set("value", value); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("found", found); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(key)
+	return nil
+}
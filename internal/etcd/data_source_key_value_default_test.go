@@ -0,0 +1,38 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestKvDefaultDataSourceRead(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/name", "passbase")
+	kv.Put(context.Background(), "/app/empty", "")
+
+	for _, tc := range []struct {
+		key   string
+		value string
+		found bool
+	}{
+		{"/app/name", "passbase", true},
+		{"/app/empty", "", true},
+		{"/app/missing", "fallback", false},
+	} {
+		d := schema.TestResourceDataRaw(test, KvDefaultDataSource().Schema, map[string]interface{}{
+			"key":     tc.key,
+			"default": "fallback",
+		})
+		if diags := kvDefaultDataSourceRead(context.Background(), d, newFakeClient(kv)); diags.HasError() {
+			test.Fatalf("%s: err: %v", tc.key, diags)
+		}
+		if got := d.Get("value").(string); got != tc.value {
+			test.Fatalf("%s: expected value %q, got %q", tc.key, tc.value, got)
+		}
+		if got := d.Get("found").(bool); got != tc.found {
+			test.Fatalf("%s: expected found %v, got %v", tc.key, tc.found, got)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestKeyValueDataSourceReadText(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/name", "passbase")
+
+	d := schema.TestResourceDataRaw(test, KeyValueDataSource().Schema, map[string]interface{}{
+		"key": "/app/name",
+	})
+	if diags := keyValueDataSourceRead(context.Background(), d, newFakeClient(kv)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	if got := d.Get("value").(string); got != "passbase" {
+		test.Fatalf("value: expected %q, got %q", "passbase", got)
+	}
+	if got := d.Get("value_base64").(string); got != "cGFzc2Jhc2U=" {
+		test.Fatalf("value_base64: expected %q, got %q", "cGFzc2Jhc2U=", got)
+	}
+	if d.Get("is_binary").(bool) {
+		test.Fatalf("is_binary: expected false")
+	}
+}
+
+func TestKeyValueDataSourceReadBinary(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/blob", string([]byte{0xff, 0xfe, 0x00}))
+
+	d := schema.TestResourceDataRaw(test, KeyValueDataSource().Schema, map[string]interface{}{
+		"key": "/app/blob",
+	})
+	if diags := keyValueDataSourceRead(context.Background(), d, newFakeClient(kv)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	if got := d.Get("value").(string); got != "" {
+		test.Fatalf("value: expected empty, got %q", got)
+	}
+	if got := d.Get("value_base64").(string); got != "//4A" {
+		test.Fatalf("value_base64: expected %q, got %q", "//4A", got)
+	}
+	if !d.Get("is_binary").(bool) {
+		test.Fatalf("is_binary: expected true")
+	}
+}
+
+func TestKeyValueDataSourceReadMetadata(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/app/name", "v1")
+	kv.Put(ctx, "/app/other", "other")
+	kv.Put(ctx, "/app/name", "v2", clientv3.WithLease(42))
+
+	d := schema.TestResourceDataRaw(test, KeyValueDataSource().Schema, map[string]interface{}{
+		"key": "/app/name",
+	})
+	if diags := keyValueDataSourceRead(ctx, d, newFakeClient(kv)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	for attribute, expected := range map[string]interface{}{
+		"version":         2,
+		"create_revision": 2,
+		"mod_revision":    4,
+		"lease":           "42",
+	} {
+		if got := d.Get(attribute); got != expected {
+			test.Fatalf("%s: expected %v, got %v", attribute, expected, got)
+		}
+	}
+}
+
+func TestKeyValueDataSourceReadMissingKey(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, KeyValueDataSource().Schema, map[string]interface{}{
+		"key": "/app/missing",
+	})
+	diags := keyValueDataSourceRead(context.Background(), d, newFakeClient(newFakeKV()))
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "/app/missing") {
+		test.Fatalf("expected an error naming the missing key, got %v", diags)
+	}
+}
+
+func TestKeyValueDataSourceReadSerializable(test *testing.T) {
+	for _, serializable := range []bool{false, true} {
+		kv := newFakeKV()
+		kv.Put(context.Background(), "/app/name", "passbase")
+
+		d := schema.TestResourceDataRaw(test, KeyValueDataSource().Schema, map[string]interface{}{
+			"key":          "/app/name",
+			"serializable": serializable,
+		})
+		if diags := keyValueDataSourceRead(context.Background(), d, newFakeClient(kv)); diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+		if got := opField(kv.gets[len(kv.gets)-1], "serializable").Bool(); got != serializable {
+			test.Fatalf("serializable=%v: the read was made with serializable=%v", serializable, got)
+		}
+	}
+}
+
+func TestKeyValueDataSourceReadAtRevision(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	first, _ := kv.Put(ctx, "/app/name", "passbase")
+	kv.Put(ctx, "/app/name", "awesome")
+
+	d := schema.TestResourceDataRaw(test, KeyValueDataSource().Schema, map[string]interface{}{
+		"key": "/app/name",
+		"rev": int(first.Header.Revision),
+	})
+	if diags := keyValueDataSourceRead(ctx, d, newFakeClient(kv)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got := d.Get("value").(string); got != "passbase" {
+		test.Fatalf("value: expected %q, got %q", "passbase", got)
+	}
+
+	kv.Compact(ctx, first.Header.Revision+1)
+	d = schema.TestResourceDataRaw(test, KeyValueDataSource().Schema, map[string]interface{}{
+		"key": "/app/name",
+		"rev": int(first.Header.Revision),
+	})
+	diags := keyValueDataSourceRead(ctx, d, newFakeClient(kv))
+	if !diags.HasError() || diags[0].Summary != "Revision was compacted" {
+		test.Fatalf("expected a compaction error, got %v", diags)
+	}
+}
@@ -0,0 +1,109 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func LeasesDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the leases of the cluster with their TTL, and optionally the keys attached to them.",
+		ReadContext: leasesDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"with_keys": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Also return the keys attached to each lease.",
+			},
+			"leases": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ID of the lease, in decimal like the `lease_id` of `etcd_lease`.",
+						},
+						"ttl": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Seconds left before the lease expires.",
+						},
+						"granted_ttl": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "TTL the lease was granted with.",
+						},
+						"keys": &schema.Schema{
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Keys attached to the lease, only set with `with_keys`.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func leasesDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	response, err := client.Leases(ctx)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to list the leases: %w", err))
+	}
+	sort.Slice(response.Leases, func(i, j int) bool {
+		return response.Leases[i].ID < response.Leases[j].ID
+	})
+
+	// etcd only lists the lease IDs, their TTL takes one request per lease.
+	opts := []clientv3.LeaseOption{}
+	if d.Get("with_keys").(bool) {
+		opts = append(opts, clientv3.WithAttachedKeys())
+	}
+
+	leases := []interface{}{}
+	for _, lease := range response.Leases {
+		ttl, err := client.TimeToLive(ctx, lease.ID, opts...)
+		if errors.Is(rpctypes.Error(err), rpctypes.ErrLeaseNotFound) {
+			continue
+		}
+		if err != nil {
+			return classifyEtcdError(fmt.Errorf("unable to read lease %s: %w", formatLeaseID(lease.ID), err))
+		}
+		// Leases that expired since they were listed have a TTL of -1.
+		if ttl.TTL <= 0 {
+			logf(ctx, "DEBUG", "lease %s expired since it was listed, skipping it", formatLeaseID(lease.ID))
+			continue
+		}
+
+		keys := []interface{}{}
+		for _, key := range ttl.Keys {
+			keys = append(keys, string(key))
+		}
+		leases = append(leases, map[string]interface{}{
+			"id":          formatLeaseID(lease.ID),
+			"ttl":         ttl.TTL,
+			"granted_ttl": ttl.GrantedTTL,
+			"keys":        keys,
+		})
+	}
+
+	if err := d.Set("leases", leases); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("leases")
+	return nil
+}
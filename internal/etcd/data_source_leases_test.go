@@ -0,0 +1,67 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// expiringLease lists a lease that expires before its TTL is read.
+type expiringLease struct {
+	*fakeLease
+	expired clientv3.LeaseID
+}
+
+func (f *expiringLease) Leases(ctx context.Context) (*clientv3.LeaseLeasesResponse, error) {
+	response, err := f.fakeLease.Leases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response.Leases = append(response.Leases, clientv3.LeaseStatus{ID: f.expired})
+	return response, nil
+}
+
+func TestLeasesDataSourceRead(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeClient(kv)
+	client.Lease = &expiringLease{fakeLease: lease, expired: 1}
+
+	first, _ := lease.Grant(ctx, 60)
+	second, _ := lease.Grant(ctx, 30)
+	kv.Put(ctx, "/locks/a", "1", clientv3.WithLease(first.ID))
+	kv.Put(ctx, "/locks/b", "2", clientv3.WithLease(first.ID))
+
+	for _, withKeys := range []bool{false, true} {
+		d := schema.TestResourceDataRaw(test, LeasesDataSource().Schema, map[string]interface{}{
+			"with_keys": withKeys,
+		})
+		if diags := leasesDataSourceRead(ctx, d, client); diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+
+		expected := map[string]interface{}{
+			"leases.#":             2,
+			"leases.0.id":          formatLeaseID(first.ID),
+			"leases.0.ttl":         60,
+			"leases.0.granted_ttl": 60,
+			"leases.0.keys.#":      0,
+			"leases.1.id":          formatLeaseID(second.ID),
+			"leases.1.ttl":         30,
+			"leases.1.keys.#":      0,
+		}
+		if withKeys {
+			expected["leases.0.keys.#"] = 2
+			expected["leases.0.keys.0"] = "/locks/a"
+			expected["leases.0.keys.1"] = "/locks/b"
+		}
+		for attribute, value := range expected {
+			if got := d.Get(attribute); got != value {
+				test.Fatalf("with_keys = %v: %s: expected %v, got %v", withKeys, attribute, value, got)
+			}
+		}
+	}
+}
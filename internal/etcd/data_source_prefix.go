@@ -0,0 +1,85 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func PrefixDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads the keys under a prefix.",
+		ReadContext: prefixDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"prefix": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"limit": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of keys to read, `0` reads every key under the prefix.",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "ascend",
+				ValidateFunc: validation.StringInSlice([]string{"ascend", "descend"}, true),
+				Description:  "Order of the keys, `ascend` or `descend`.",
+			},
+			"serializable": serializableSchema(),
+			"keys": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"values": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func prefixDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	prefix := d.Get("prefix").(string)
+
+	order := clientv3.SortAscend
+	if strings.EqualFold(d.Get("sort_order").(string), "descend") {
+		order = clientv3.SortDescend
+	}
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, order)}
+	if limit := d.Get("limit").(int); limit > 0 {
+		opts = append(opts, clientv3.WithLimit(int64(limit)))
+	}
+	opts = append(opts, readConsistency(d)...)
+
+	response, err := client.Get(ctx, prefix, opts...)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to read the keys under %q: %w", prefix, err))
+	}
+
+	keys := []string{}
+	values := map[string]string{}
+	for _, kv := range response.Kvs {
+		keys = append(keys, string(kv.Key))
+		values[string(kv.Key)] = string(kv.Value)
+	}
+
+	if err := d.Set("keys", keys); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("values", values); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(prefix)
+	return nil
+}
@@ -0,0 +1,46 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func PrefixCountDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Counts the keys under a prefix without reading their values.",
+		ReadContext: prefixCountDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"prefix": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"serializable": serializableSchema(),
+			"key_count": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of keys under the prefix, nested ones included.",
+			},
+		},
+	}
+}
+
+func prefixCountDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	prefix := d.Get("prefix").(string)
+
+	opts := append([]clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithCountOnly()}, readConsistency(d)...)
+	response, err := client.Get(ctx, prefix, opts...)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to count the keys under %q: %w", prefix, err))
+	}
+
+	if err := d.Set("key_count", int(response.Count)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(prefix)
+	return nil
+}
@@ -0,0 +1,37 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestPrefixCountDataSourceRead(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/app/name", "passbase")
+	kv.Put(ctx, "/app/config/db/url", "postgres://")
+	kv.Put(ctx, "/app/config/db/pool/size", "10")
+	kv.Put(ctx, "/other/name", "ignored")
+
+	for prefix, expected := range map[string]int{
+		"/app/":               3,
+		"/app/config/db/":     2,
+		"/app/config/db/pool": 1,
+		"/missing/":           0,
+	} {
+		d := schema.TestResourceDataRaw(test, PrefixCountDataSource().Schema, map[string]interface{}{
+			"prefix": prefix,
+		})
+		if diags := prefixCountDataSourceRead(ctx, d, newFakeClient(kv)); diags.HasError() {
+			test.Fatalf("%s: err: %v", prefix, diags)
+		}
+		if got := d.Get("key_count").(int); got != expected {
+			test.Fatalf("%s: expected %d keys, got %d", prefix, expected, got)
+		}
+		if !kv.gets[len(kv.gets)-1].IsCountOnly() {
+			test.Fatalf("%s: expected a count only read", prefix)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package etcd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestPrefixDataSourceRead(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/app/b", "2")
+	kv.Put(ctx, "/app/a", "1")
+	kv.Put(ctx, "/app/c", "3")
+	kv.Put(ctx, "/other/a", "other")
+	expected := map[string]interface{}{"/app/a": "1", "/app/b": "2", "/app/c": "3"}
+
+	for _, tc := range []struct {
+		name   string
+		config map[string]interface{}
+		keys   []interface{}
+	}{
+		{"ascend", map[string]interface{}{}, []interface{}{"/app/a", "/app/b", "/app/c"}},
+		{"descend", map[string]interface{}{"sort_order": "descend"}, []interface{}{"/app/c", "/app/b", "/app/a"}},
+		{"limit", map[string]interface{}{"limit": 2}, []interface{}{"/app/a", "/app/b"}},
+		{"limit descend", map[string]interface{}{"limit": 1, "sort_order": "descend"}, []interface{}{"/app/c"}},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			tc.config["prefix"] = "/app/"
+			d := schema.TestResourceDataRaw(test, PrefixDataSource().Schema, tc.config)
+			if diags := prefixDataSourceRead(ctx, d, newFakeClient(kv)); diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+
+			if keys := d.Get("keys").([]interface{}); !reflect.DeepEqual(keys, tc.keys) {
+				test.Fatalf("keys: expected %v, got %v", tc.keys, keys)
+			}
+			values := d.Get("values").(map[string]interface{})
+			if len(values) != len(tc.keys) {
+				test.Fatalf("values: expected %d keys, got %v", len(tc.keys), values)
+			}
+			for _, key := range tc.keys {
+				if values[key.(string)] != expected[key.(string)] {
+					test.Fatalf("values: unexpected value for %s in %v", key, values)
+				}
+			}
+		})
+	}
+}
+
+func TestPrefixDataSourceReadSerializable(test *testing.T) {
+	kv := newFakeKV()
+	d := schema.TestResourceDataRaw(test, PrefixDataSource().Schema, map[string]interface{}{
+		"prefix":       "/app/",
+		"serializable": true,
+	})
+	if diags := prefixDataSourceRead(context.Background(), d, newFakeClient(kv)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if !opField(kv.gets[len(kv.gets)-1], "serializable").Bool() {
+		test.Fatalf("expected the read to be serializable")
+	}
+}
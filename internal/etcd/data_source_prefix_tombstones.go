@@ -0,0 +1,94 @@
+package etcd
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func PrefixTombstonesDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Watches a prefix for a window of time and reports the keys deleted under it, with the revision of each delete.",
+		ReadContext: prefixTombstonesDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"prefix": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"window": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+				Description:  "How long to watch for deletes, as a duration such as `30s`.",
+			},
+			"start_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Also report deletes since this revision, as long as it was not compacted.",
+			},
+			"deleted": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"revision": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func prefixTombstonesDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	prefix := d.Get("prefix").(string)
+	window, _ := time.ParseDuration(d.Get("window").(string))
+
+	watchCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithFilterPut()}
+	if rev := d.Get("start_revision").(int); rev > 0 {
+		opts = append(opts, clientv3.WithRev(int64(rev)))
+	}
+
+	deleted := []interface{}{}
+	for response := range client.Watch(clientv3.WithRequireLeader(watchCtx), prefix, opts...) {
+		if err := response.Err(); err != nil && watchCtx.Err() == nil {
+			return classifyEtcdError(err)
+		}
+		for _, event := range response.Events {
+			if event.Type != mvccpb.DELETE {
+				continue
+			}
+			deleted = append(deleted, map[string]interface{}{
+				"key":      string(event.Kv.Key),
+				"revision": event.Kv.ModRevision,
+			})
+		}
+	}
+	// The watch channel closes when the window elapsed, but also when the
+	// Terraform operation itself was cancelled.
+	if err := ctx.Err(); err != nil {
+		return classifyEtcdError(err)
+	}
+
+	if err := d.Set("deleted", deleted); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(prefix)
+	return nil
+}
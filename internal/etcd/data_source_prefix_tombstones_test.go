@@ -0,0 +1,54 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestPrefixTombstonesReportsDeletes(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeWatchClient(kv)
+	kv.Put(ctx, "/jobs/a", "1")
+	kv.Put(ctx, "/jobs/b", "2")
+	kv.Put(ctx, "/other/c", "3")
+
+	d := schema.TestResourceDataRaw(test, PrefixTombstonesDataSource().Schema, map[string]interface{}{
+		"prefix": "/jobs/",
+		"window": "300ms",
+	})
+
+	done := make(chan diag.Diagnostics)
+	go func() {
+		done <- prefixTombstonesDataSourceRead(ctx, d, client)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	kv.Delete(ctx, "/jobs/a")
+	kv.Put(ctx, "/jobs/new", "4")
+	kv.Delete(ctx, "/other/c")
+	kv.Delete(ctx, "/jobs/b")
+
+	if diags := <-done; diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	deleted := d.Get("deleted").([]interface{})
+	expected := []map[string]interface{}{
+		{"key": "/jobs/a", "revision": 5},
+		{"key": "/jobs/b", "revision": 8},
+	}
+	if len(deleted) != len(expected) {
+		test.Fatalf("expected %v, got %v", expected, deleted)
+	}
+	for i, raw := range deleted {
+		got := raw.(map[string]interface{})
+		if got["key"] != expected[i]["key"] || got["revision"] != expected[i]["revision"] {
+			test.Fatalf("expected %v, got %v", expected, deleted)
+		}
+	}
+}
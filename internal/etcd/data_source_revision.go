@@ -0,0 +1,41 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func RevisionDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads the current revision of the cluster.",
+		ReadContext: revisionDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Current revision of the cluster, increased by every write.",
+			},
+		},
+	}
+}
+
+// revisionDataSourceRead reads the revision from the header of a count-only
+// read, which returns no key and works on an empty cluster.
+func revisionDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	response, err := client.Get(ctx, "\x00", clientv3.WithCountOnly())
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to read the cluster revision: %w", err))
+	}
+
+	if err := d.Set("revision", int(response.Header.Revision)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("revision")
+	return nil
+}
@@ -0,0 +1,31 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRevisionDataSourceRead(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+
+	read := func() int {
+		d := schema.TestResourceDataRaw(test, RevisionDataSource().Schema, map[string]interface{}{})
+		if diags := revisionDataSourceRead(ctx, d, client); diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+		return d.Get("revision").(int)
+	}
+
+	empty := read()
+	if empty != 1 {
+		test.Fatalf("expected the revision of an empty cluster to be 1, got %d", empty)
+	}
+	kv.Put(ctx, "/app/name", "passbase")
+	if written := read(); written <= empty {
+		test.Fatalf("expected the revision to increase after a write, got %d then %d", empty, written)
+	}
+}
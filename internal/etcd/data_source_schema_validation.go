@@ -0,0 +1,90 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func KvSchemaValidateDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Validates the JSON value of a key against a JSON Schema.",
+		ReadContext: kvSchemaValidateDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"key": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+			"schema": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateJSONSchemaText,
+				Description:  "JSON Schema the value must conform to, using the subset of draft 7 described in the documentation. `pattern` uses the Go RE2 syntax.",
+			},
+			"serializable": serializableSchema(),
+			"valid": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the value is JSON conforming to `schema`.",
+			},
+			"errors": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Violations of `schema`, each prefixed with the JSON pointer of the offending value, or the parse error of a value that is not JSON.",
+			},
+			"mod_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Cluster revision at which the validated value was written.",
+			},
+		},
+	}
+}
+
+func kvSchemaValidateDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	key := d.Get("key").(string)
+	jsonSchema, err := parseJSONSchema(d.Get("schema").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	response, err := client.Get(ctx, key, readConsistency(d)...)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if len(response.Kvs) == 0 {
+		return diag.Errorf("key %q does not exist", key)
+	}
+
+	// A value that is not JSON is reported as invalid, not as a failure, so
+	// pipelines can assert on valid alone.
+	errs := []string{}
+	var document interface{}
+	if err := json.Unmarshal(response.Kvs[0].Value, &document); err != nil {
+		errs = append(errs, fmt.Sprintf("value is not valid JSON: %v", err))
+	} else {
+		errs = validateJSONDocument(jsonSchema, document, "")
+	}
+	logf(ctx, "DEBUG", "validated key %q against its schema: %d errors", key, len(errs))
+
+	if err := d.Set("valid", len(errs) == 0); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("errors", errs); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("mod_revision", response.Kvs[0].ModRevision); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(key)
+	return nil
+}
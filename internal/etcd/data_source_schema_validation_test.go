@@ -0,0 +1,95 @@
+package etcd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const deploymentSchema = `{
+	"type": "object",
+	"required": ["name", "replicas"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "pattern": "^[a-z-]+$"},
+		"replicas": {"type": "integer", "minimum": 1, "maximum": 10},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+		"mode": {"enum": ["active", "standby"]}
+	}
+}`
+
+func TestKvSchemaValidateDataSourceRead(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+
+	for _, tc := range []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"valid", `{"name": "api", "replicas": 3, "tags": ["a", "b"], "mode": "active"}`, []string{}},
+		{"violations", `{"name": "API", "replicas": 0, "tags": ["a", "a", 1], "mode": "off", "extra": true}`, []string{
+			`/: additional property "extra" is not allowed`,
+			`/mode: value is not one of the enum values`,
+			`/name: "API" does not match the pattern "^[a-z-]+$"`,
+			`/replicas: 0 is less than the minimum 1`,
+			`/tags/2: expected string, got integer`,
+			`/tags: items 0 and 1 are equal`,
+		}},
+		{"missing", `{"replicas": 2.5}`, []string{
+			`/: missing required property "name"`,
+			`/replicas: expected integer, got number`,
+		}},
+		{"not JSON", `name: api`, []string{"value is not valid JSON: invalid character 'a' in literal null (expecting 'u')"}},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			kv.Put(ctx, "/app/deployment", tc.value)
+			d := schema.TestResourceDataRaw(test, KvSchemaValidateDataSource().Schema, map[string]interface{}{
+				"key":    "/app/deployment",
+				"schema": deploymentSchema,
+			})
+			if diags := kvSchemaValidateDataSourceRead(ctx, d, client); diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+			errs := []string{}
+			for _, err := range d.Get("errors").([]interface{}) {
+				errs = append(errs, err.(string))
+			}
+			if !reflect.DeepEqual(errs, tc.expected) || d.Get("valid").(bool) != (len(tc.expected) == 0) {
+				test.Fatalf("expected errors %q, got %q (valid %v)", tc.expected, errs, d.Get("valid"))
+			}
+		})
+	}
+}
+
+func TestValidateJSONSchemaText(test *testing.T) {
+	for text, valid := range map[string]bool{
+		deploymentSchema: true,
+		`true`:           true,
+		`{"anyOf": [{"type": "string"}, {"not": {"const": 1}}]}`: true,
+		`{"$ref": "#/definitions/name"}`:                         false,
+		`{"pattern": "("}`:                                       false,
+		`{"items": [{"type": "string"}]}`:                        false,
+		`{"type": "object"`:                                      false,
+	} {
+		if _, errs := validateJSONSchemaText(text, "schema"); (len(errs) == 0) != valid {
+			test.Fatalf("%s: expected valid %v, got %v", text, valid, errs)
+		}
+	}
+}
+
+func TestValidateJSONSchemaTextUnsupportedKeyword(test *testing.T) {
+	_, errs := validateJSONSchemaText(`{"properties": {"name": {"$ref": "#/definitions/name"}}}`, "schema")
+	if len(errs) != 1 {
+		test.Fatalf("expected a single error, got %v", errs)
+	}
+	for _, expected := range []string{`/properties/name`, `"$ref"`, "etcd_schema_validation documentation", "allOf, anyOf"} {
+		if !strings.Contains(errs[0].Error(), expected) {
+			test.Fatalf("expected the error to mention %s, got %q", expected, errs[0])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func SnapshotDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Saves a snapshot of the cluster to a local file.",
+		ReadContext: snapshotDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"path": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Local file to save the snapshot to, replaced when it exists.",
+			},
+			"timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "5m",
+				ValidateFunc: validateDuration,
+				Description:  "How long downloading the snapshot may take, as a duration such as `5m`.",
+			},
+			"size": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Size of the snapshot in bytes.",
+			},
+			"sha256": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Hex encoded SHA-256 checksum of the snapshot file.",
+			},
+		},
+	}
+}
+
+func snapshotDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+	path := d.Get("path").(string)
+
+	// The download of a large database outlasts request_timeout.
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logf(ctx, "DEBUG", "saving a snapshot to %s, waiting up to %s", path, timeout)
+	size, sum, err := saveSnapshot(ctx, client, path)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to save a snapshot to %s: %w", path, err))
+	}
+
+	if err := d.Set("size", size); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("sha256", sum); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(path)
+	return nil
+}
+
+// saveSnapshot streams a snapshot to a temporary file next to path and only
+// moves it to path once it was fully received and verified, so a failed
+// download never leaves a truncated snapshot behind.
+func saveSnapshot(ctx context.Context, client *apiClient, path string) (int64, string, error) {
+	stream, err := client.Snapshot(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer stream.Close()
+
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".part")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), stream)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := verifySnapshot(file.Name(), size); err != nil {
+		return 0, "", err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifySnapshot checks the SHA-256 etcd appends to the database it streams.
+func verifySnapshot(path string, size int64) error {
+	if size < sha256.Size {
+		return fmt.Errorf("snapshot is too short: %d bytes", size)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, file, size-sha256.Size); err != nil {
+		return err
+	}
+	expected := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(file, expected); err != nil {
+		return err
+	}
+	if !bytes.Equal(hash.Sum(nil), expected) {
+		return fmt.Errorf("snapshot checksum mismatch, the download is corrupted")
+	}
+	return nil
+}
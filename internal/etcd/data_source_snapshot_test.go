@@ -0,0 +1,100 @@
+package etcd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func newSnapshotTestClient(maintenance *fakeMaintenance) *apiClient {
+	client := newFakeClient(newFakeKV())
+	client.Maintenance = maintenance
+	return client
+}
+
+func TestSnapshotDataSourceRead(test *testing.T) {
+	maintenance := newFakeMaintenance()
+	maintenance.setSnapshot([]byte("bolt database"))
+	path := filepath.Join(test.TempDir(), "etcd.db")
+
+	d := schema.TestResourceDataRaw(test, SnapshotDataSource().Schema, map[string]interface{}{
+		"path": path,
+	})
+	if diags := snapshotDataSourceRead(context.Background(), d, newSnapshotTestClient(maintenance)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	sum := sha256.Sum256(saved)
+	if string(saved) != string(maintenance.snapshot) {
+		test.Fatalf("expected the snapshot to be saved as streamed")
+	}
+	if got := d.Get("size").(int); got != len(saved) {
+		test.Fatalf("size: expected %d, got %d", len(saved), got)
+	}
+	if got := d.Get("sha256").(string); got != hex.EncodeToString(sum[:]) {
+		test.Fatalf("sha256: expected %x, got %s", sum, got)
+	}
+}
+
+func TestSnapshotDataSourceReadFailures(test *testing.T) {
+	for name, maintenance := range map[string]*fakeMaintenance{
+		"interrupted": {snapshot: []byte("bolt"), snapshotErr: errors.New("stream reset")},
+		"corrupted":   {snapshot: append([]byte("bolt database"), make([]byte, sha256.Size)...)},
+		"truncated":   {snapshot: []byte("bolt")},
+	} {
+		dir := test.TempDir()
+		path := filepath.Join(dir, "etcd.db")
+
+		d := schema.TestResourceDataRaw(test, SnapshotDataSource().Schema, map[string]interface{}{
+			"path": path,
+		})
+		if diags := snapshotDataSourceRead(context.Background(), d, newSnapshotTestClient(maintenance)); !diags.HasError() {
+			test.Fatalf("%s: expected an error", name)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			test.Fatalf("%s: expected no snapshot to be saved, got %v", name, err)
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+			test.Fatalf("%s: expected the partial download to be removed, got %d files", name, len(files))
+		}
+	}
+}
+
+func TestSnapshotDataSourceTimeout(test *testing.T) {
+	maintenance := newFakeMaintenance()
+	maintenance.snapshotStalled = true
+	path := filepath.Join(test.TempDir(), "etcd.db")
+
+	d := schema.TestResourceDataRaw(test, SnapshotDataSource().Schema, map[string]interface{}{
+		"path":    path,
+		"timeout": "50ms",
+	})
+	diags := snapshotDataSourceRead(context.Background(), d, newSnapshotTestClient(maintenance))
+	if !diags.HasError() || diags[0].Summary != "Operation timed out" {
+		test.Fatalf("expected the download to time out, got %v", diags)
+	}
+
+	// The provider request_timeout does not cut the download short.
+	maintenance = newFakeMaintenance()
+	maintenance.setSnapshot([]byte("bolt database"))
+	client := newSnapshotTestClient(maintenance)
+	client.requestTimeout = time.Nanosecond
+	d = schema.TestResourceDataRaw(test, SnapshotDataSource().Schema, map[string]interface{}{
+		"path": path,
+	})
+	if diags := New().DataSourcesMap["etcd_snapshot"].ReadContext(context.Background(), d, client); diags.HasError() {
+		test.Fatalf("expected the snapshot not to be bound by request_timeout, got %v", diags)
+	}
+}
@@ -29,7 +29,7 @@ func usersDataSourceRead(ctx context.Context, d *schema.ResourceData, meta inter
 	users, err := client.UserList(ctx)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	if err := d.Set("users", users.Users); err != nil {
 
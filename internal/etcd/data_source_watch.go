@@ -0,0 +1,125 @@
+package etcd
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func KvWatchDataSource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Watches a key or a prefix and returns the next events written to it, blocking until enough events arrived or the timeout elapsed.",
+		ReadContext: kvWatchDataSourceRead,
+		Schema: map[string]*schema.Schema{
+			"key": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"key", "prefix"},
+				Description:  "Key to watch.",
+			},
+			"prefix": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Prefix of the keys to watch.",
+			},
+			"event_count": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Number of events to wait for.",
+			},
+			"timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "30s",
+				ValidateFunc: validateDuration,
+				Description:  "How long to wait for the events, as a duration such as `30s`.",
+			},
+			"events": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"key": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"value": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"revision": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// kvWatchDataSourceRead collects the events written after the read started,
+// returning once event_count of them arrived or with the events collected so
+// far when the timeout elapsed.
+func kvWatchDataSourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	key, opts := d.Get("key").(string), []clientv3.OpOption{}
+	if prefix := d.Get("prefix").(string); prefix != "" {
+		key, opts = prefix, append(opts, clientv3.WithPrefix())
+	}
+	count := d.Get("event_count").(int)
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+
+	// Cancelling the context closes the watch channel once enough events
+	// were collected, so the watch never outlives the read.
+	watchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logf(ctx, "DEBUG", "waiting up to %s for %d events on %q", timeout, count, key)
+	events := []interface{}{}
+	for response := range client.Watch(clientv3.WithRequireLeader(watchCtx), key, opts...) {
+		if err := response.Err(); err != nil && watchCtx.Err() == nil {
+			return classifyEtcdError(err)
+		}
+		for _, event := range response.Events {
+			if len(events) == count {
+				break
+			}
+			events = append(events, map[string]interface{}{
+				"type":     event.Type.String(),
+				"key":      string(event.Kv.Key),
+				"value":    string(event.Kv.Value),
+				"revision": event.Kv.ModRevision,
+			})
+		}
+		if len(events) == count {
+			cancel()
+		}
+	}
+	// The watch channel closes when the timeout elapsed, but also when the
+	// Terraform operation itself was cancelled.
+	if err := ctx.Err(); err != nil {
+		return classifyEtcdError(err)
+	}
+
+	if len(events) < count {
+		logf(ctx, "DEBUG", "timed out after %d of %d events on %q", len(events), count, key)
+	}
+	if err := d.Set("events", events); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(key)
+	return nil
+}
@@ -0,0 +1,91 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestKvWatchCollectsEvents(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeWatchClient(kv)
+	kv.Put(ctx, "/jobs/old", "0")
+
+	d := schema.TestResourceDataRaw(test, KvWatchDataSource().Schema, map[string]interface{}{
+		"prefix":      "/jobs/",
+		"event_count": 2,
+		"timeout":     "5s",
+	})
+
+	done := make(chan diag.Diagnostics)
+	go func() {
+		done <- kvWatchDataSourceRead(ctx, d, client)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	kv.Put(ctx, "/other/a", "1")
+	kv.Put(ctx, "/jobs/a", "2")
+	kv.Delete(ctx, "/jobs/old")
+	kv.Put(ctx, "/jobs/b", "3")
+
+	select {
+	case diags := <-done:
+		if diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+	case <-time.After(5 * time.Second):
+		test.Fatalf("the watch did not return after the events arrived")
+	}
+
+	events := d.Get("events").([]interface{})
+	expected := []map[string]interface{}{
+		{"type": "PUT", "key": "/jobs/a", "value": "2", "revision": 4},
+		{"type": "DELETE", "key": "/jobs/old", "value": "", "revision": 5},
+	}
+	if len(events) != len(expected) {
+		test.Fatalf("expected %v, got %v", expected, events)
+	}
+	for i, raw := range events {
+		got := raw.(map[string]interface{})
+		for field, value := range expected[i] {
+			if got[field] != value {
+				test.Fatalf("expected %v, got %v", expected, events)
+			}
+		}
+	}
+	if d.Id() != "/jobs/" {
+		test.Fatalf("expected the prefix as ID, got %q", d.Id())
+	}
+}
+
+func TestKvWatchTimeoutReturnsCollectedEvents(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeWatchClient(kv)
+
+	d := schema.TestResourceDataRaw(test, KvWatchDataSource().Schema, map[string]interface{}{
+		"key":         "/deploy/status",
+		"event_count": 3,
+		"timeout":     "300ms",
+	})
+
+	done := make(chan diag.Diagnostics)
+	go func() {
+		done <- kvWatchDataSourceRead(ctx, d, client)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	kv.Put(ctx, "/deploy/status", "ready")
+
+	if diags := <-done; diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	events := d.Get("events").([]interface{})
+	if len(events) != 1 || events[0].(map[string]interface{})["value"] != "ready" {
+		test.Fatalf("expected the single event written before the timeout, got %v", events)
+	}
+}
@@ -0,0 +1,32 @@
+package etcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// deleteProtectionSchema is the delete_protection argument of the resources
+// owning keys.
+func deleteProtectionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Refuse to delete the keys, including on replacement, until it is turned off and applied.",
+	}
+}
+
+// checkDeleteProtection refuses to delete what when delete_protection is set
+// in the state being destroyed.
+func checkDeleteProtection(d *schema.ResourceData, what string) diag.Diagnostics {
+	if !d.Get("delete_protection").(bool) {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Delete protection is enabled",
+		Detail:   fmt.Sprintf("Refusing to delete %s because delete_protection is set. Set delete_protection to false and apply before destroying or replacing it.", what),
+	}}
+}
@@ -0,0 +1,50 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestDeleteProtection(test *testing.T) {
+	for name, tc := range map[string]struct {
+		resource *schema.Resource
+		config   map[string]interface{}
+	}{
+		"etcd_key_value": {KvResource(), map[string]interface{}{
+			"key":   "/app/name",
+			"value": "passbase",
+		}},
+		"etcd_prefix": {PrefixResource(), map[string]interface{}{
+			"prefix": "/app/",
+			"values": map[string]interface{}{"name": "passbase"},
+		}},
+	} {
+		kv := newFakeKV()
+		client := newFakeClient(kv)
+
+		tc.config["delete_protection"] = true
+		state, diags := applyTestResource(test, tc.resource, nil, tc.config, client)
+		if diags.HasError() {
+			test.Fatalf("%s: err: %v", name, diags)
+		}
+
+		if _, diags = applyTestResource(test, tc.resource, state, nil, client); !diags.HasError() || diags[0].Summary != "Delete protection is enabled" {
+			test.Fatalf("%s: expected the destroy to be refused, got %v", name, diags)
+		}
+		assertKeyValue(test, kv, "/app/name", "passbase")
+
+		tc.config["delete_protection"] = false
+		if state, diags = applyTestResource(test, tc.resource, state, tc.config, client); diags.HasError() {
+			test.Fatalf("%s: err: %v", name, diags)
+		}
+		if _, diags = applyTestResource(test, tc.resource, state, nil, client); diags.HasError() {
+			test.Fatalf("%s: expected the destroy to succeed once unprotected, got %v", name, diags)
+		}
+		if response, _ := kv.Get(context.Background(), "/app/", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+			test.Fatalf("%s: expected the keys to be deleted, got %v", name, response.Kvs)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// srvResolver looks up SRV records, as net.Resolver does.
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// discoveryResolver resolves the SRV records of discovery_srv.
+var discoveryResolver srvResolver = net.DefaultResolver
+
+// discoverEndpoints resolves the client endpoints of the cluster published
+// under domain, like etcdctl --discovery-srv: the _etcd-client-ssl._tcp
+// records are dialed with TLS, and the _etcd-client._tcp records are used
+// when there are none.
+func discoverEndpoints(ctx context.Context, resolver srvResolver, domain string) ([]string, diag.Diagnostics) {
+	failures := []string{}
+	for _, service := range []struct{ name, scheme string }{
+		{"etcd-client-ssl", "https"},
+		{"etcd-client", "http"},
+	} {
+		_, records, err := resolver.LookupSRV(ctx, service.name, "tcp", domain)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("_%s._tcp.%s: %v", service.name, domain, err))
+			continue
+		}
+		endpoints := []string{}
+		for _, record := range records {
+			host := strings.TrimSuffix(record.Target, ".")
+			endpoints = append(endpoints, fmt.Sprintf("%s://%s", service.scheme, net.JoinHostPort(host, strconv.Itoa(int(record.Port)))))
+		}
+		if len(endpoints) > 0 {
+			return endpoints, nil
+		}
+		failures = append(failures, fmt.Sprintf("_%s._tcp.%s: no record", service.name, domain))
+	}
+	return nil, diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Unable to discover the etcd endpoints",
+		Detail:   fmt.Sprintf("No SRV record of domain %q, set by discovery_srv, resolved to an endpoint:\n\n%s", domain, strings.Join(failures, "\n")),
+	}}
+}
@@ -0,0 +1,81 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// stubResolver answers SRV lookups from records, keyed by _service._proto.name.
+type stubResolver map[string][]*net.SRV
+
+func (r stubResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	cname := fmt.Sprintf("_%s._%s.%s", service, proto, name)
+	records, ok := r[cname]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: cname, IsNotFound: true}
+	}
+	return cname, records, nil
+}
+
+func TestConfigureDiscoverySRV(test *testing.T) {
+	resolver := discoveryResolver
+	defer func() { discoveryResolver = resolver }()
+
+	records := []*net.SRV{}
+	expected := []string{}
+	for i := 0; i < 2; i++ {
+		host, port, _ := net.SplitHostPort(serveKV(test))
+		portNumber, _ := strconv.Atoi(port)
+		records = append(records, &net.SRV{Target: host + ".", Port: uint16(portNumber)})
+		expected = append(expected, fmt.Sprintf("http://%s:%s", host, port))
+	}
+	discoveryResolver = stubResolver{"_etcd-client._tcp.example.com": records}
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"discovery_srv": "example.com",
+		"check_health":  false,
+	})
+	meta, diags := configure(context.Background(), d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	if !reflect.DeepEqual(client.endpoints, expected) {
+		test.Fatalf("expected the endpoints %v to be discovered, got %v", expected, client.endpoints)
+	}
+	if _, err := client.Put(context.Background(), "/app/name", "passbase"); err != nil {
+		test.Fatalf("err: %s", err)
+	}
+
+	d = schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"discovery_srv": "missing.example.com",
+		"check_health":  false,
+	})
+	if _, diags := configure(context.Background(), d); !diags.HasError() || diags[0].Summary != "Unable to discover the etcd endpoints" || !strings.Contains(diags[0].Detail, "_etcd-client._tcp.missing.example.com") {
+		test.Fatalf("expected the DNS failure to be reported, got %v", diags)
+	}
+}
+
+func TestDiscoverEndpointsPrefersTLS(test *testing.T) {
+	resolver := stubResolver{
+		"_etcd-client-ssl._tcp.example.com": {{Target: "etcd-0.example.com.", Port: 2379}, {Target: "etcd-1.example.com.", Port: 2379}},
+		"_etcd-client._tcp.example.com":     {{Target: "etcd-0.example.com.", Port: 2380}},
+	}
+	endpoints, diags := discoverEndpoints(context.Background(), resolver, "example.com")
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	expected := []string{"https://etcd-0.example.com:2379", "https://etcd-1.example.com:2379"}
+	if !reflect.DeepEqual(endpoints, expected) {
+		test.Fatalf("expected %v, got %v", expected, endpoints)
+	}
+}
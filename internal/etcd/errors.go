@@ -0,0 +1,102 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// classifyEtcdError describes an error returned by etcd, with a hint at the
+// likely fix for the errors users commonly run into. Other errors are
+// reported as is.
+func classifyEtcdError(err error) diag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+	err = rpctypes.Error(err)
+	// Raw gRPC errors wrapped with context are not converted above.
+	cause := err
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		cause = rpctypes.Error(grpcErr.(error))
+	}
+	is := func(target error) bool {
+		return errors.Is(err, target) || errors.Is(cause, target)
+	}
+
+	var summary, detail string
+	switch {
+	case is(context.Canceled):
+		summary = "Operation was cancelled"
+		detail = "Terraform was interrupted before etcd answered, the change may or may not have been applied. Refresh to read the current state."
+	case is(context.DeadlineExceeded):
+		summary = "Operation timed out"
+		detail = "etcd did not answer in time, the change may or may not have been applied. Refresh to read the current state."
+	case is(rpctypes.ErrEmptyKey):
+		summary = "Key is empty"
+	case is(rpctypes.ErrPermissionDenied):
+		summary = "Permission denied"
+		detail = "The provider user is not granted the permission on the keys this operation needs."
+	case is(rpctypes.ErrAuthNotEnabled):
+		summary = "Authentication is not enabled"
+		detail = "The provider is configured with credentials, but authentication is not enabled on the cluster."
+	case is(rpctypes.ErrLeaseNotFound):
+		summary = "Lease not found"
+		detail = "The lease expired or was revoked."
+	case is(rpctypes.ErrCompacted):
+		summary = "Revision was compacted"
+		detail = "The history up to the requested revision was compacted."
+	case is(errNoQuorum):
+		summary = "etcd cluster has no quorum"
+		detail = "The cluster did not regain a leader and a majority of healthy endpoints within wait_timeout, nothing was written. Apply again once the cluster recovered, or raise wait_timeout and request_timeout for longer restarts."
+	case isLeaderElection(err):
+		summary = "etcd leader election in progress"
+		detail = "The cluster had no leader for as long as the provider retried the request. Apply again once the election completed, or raise max_retries for longer rolling restarts."
+	case isUnavailable(err):
+		summary = "etcd cluster unavailable"
+		detail = "No endpoint could serve the request. Check that the endpoints are reachable and the cluster has a leader."
+	default:
+		return diag.FromErr(err)
+	}
+
+	if detail == "" {
+		detail = err.Error()
+	} else {
+		detail = fmt.Sprintf("%s %v", detail, err)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  summary,
+		Detail:   detail,
+	}}
+}
+
+// isLeaderElection reports whether etcd rejected the request because the
+// cluster has no leader, before proposing it.
+func isLeaderElection(err error) bool {
+	if err == nil {
+		return false
+	}
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		err = grpcErr.(error)
+	}
+	err = rpctypes.Error(err)
+	return errors.Is(err, rpctypes.ErrNoLeader) || errors.Is(err, rpctypes.ErrNotLeader)
+}
+
+// isUnavailable reports whether err is a gRPC Unavailable error, either raw
+// or converted by rpctypes, and possibly wrapped.
+func isUnavailable(err error) bool {
+	var etcdErr rpctypes.EtcdError
+	if errors.As(err, &etcdErr) {
+		return etcdErr.Code() == codes.Unavailable
+	}
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	return errors.As(err, &grpcErr) && grpcErr.GRPCStatus().Code() == codes.Unavailable
+}
@@ -0,0 +1,35 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyEtcdError(test *testing.T) {
+	if diags := classifyEtcdError(nil); diags != nil {
+		test.Fatalf("expected no diagnostics without an error, got %v", diags)
+	}
+
+	for _, tc := range []struct {
+		err     error
+		summary string
+	}{
+		{fmt.Errorf("unable to read keys: %w", context.Canceled), "Operation was cancelled"},
+		{fmt.Errorf("unable to read keys: %w", rpctypes.ErrGRPCPermissionDenied), "Permission denied"},
+		{fmt.Errorf("unable to read keys: %w", status.Error(codes.Unavailable, "connection refused")), "etcd cluster unavailable"},
+		{rpctypes.ErrGRPCCompacted, "Revision was compacted"},
+		{fmt.Errorf("unable to write key: %w", rpctypes.ErrGRPCNoLeader), "etcd leader election in progress"},
+		{errors.New("boom"), "boom"},
+	} {
+		diags := classifyEtcdError(tc.err)
+		if !diags.HasError() || diags[0].Summary != tc.summary {
+			test.Fatalf("%v: expected %q, got %v", tc.err, tc.summary, diags)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeAuth is an in-memory clientv3.Auth holding users, roles and their
+// permissions. Methods that are not needed by the resources are left to the
+// embedded interface and panic when called.
+type fakeAuth struct {
+	clientv3.Auth
+
+	mu       sync.Mutex
+	enabled  bool
+	revision uint64
+	users    map[string]*authpb.User
+	roles    map[string]*authpb.Role
+}
+
+func newFakeAuth() *fakeAuth {
+	return &fakeAuth{
+		users: map[string]*authpb.User{},
+		roles: map[string]*authpb.Role{},
+	}
+}
+
+// newFakeAuthClient returns an apiClient whose Auth is backed by auth.
+func newFakeAuthClient(auth *fakeAuth) *apiClient {
+	return &apiClient{Client: &clientv3.Client{Auth: auth}}
+}
+
+func (f *fakeAuth) AuthEnable(ctx context.Context) (*clientv3.AuthEnableResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	root, ok := f.users["root"]
+	if !ok {
+		return nil, rpctypes.ErrRootUserNotExist
+	}
+	hasRootRole := false
+	for _, role := range root.Roles {
+		hasRootRole = hasRootRole || role == "root"
+	}
+	if !hasRootRole {
+		return nil, rpctypes.ErrRootRoleNotExist
+	}
+	f.enabled = true
+	f.revision++
+	return &clientv3.AuthEnableResponse{}, nil
+}
+
+func (f *fakeAuth) AuthDisable(ctx context.Context) (*clientv3.AuthDisableResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enabled = false
+	f.revision++
+	return &clientv3.AuthDisableResponse{}, nil
+}
+
+func (f *fakeAuth) AuthStatus(ctx context.Context) (*clientv3.AuthStatusResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &clientv3.AuthStatusResponse{Enabled: f.enabled, AuthRevision: f.revision}, nil
+}
+
+func (f *fakeAuth) UserAdd(ctx context.Context, name string, password string) (*clientv3.AuthUserAddResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.users[name]; ok {
+		return nil, rpctypes.ErrUserAlreadyExist
+	}
+	f.users[name] = &authpb.User{Name: []byte(name), Password: []byte(password)}
+	f.revision++
+	return &clientv3.AuthUserAddResponse{}, nil
+}
+
+func (f *fakeAuth) UserDelete(ctx context.Context, name string) (*clientv3.AuthUserDeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.users[name]; !ok {
+		return nil, rpctypes.ErrUserNotFound
+	}
+	delete(f.users, name)
+	f.revision++
+	return &clientv3.AuthUserDeleteResponse{}, nil
+}
+
+func (f *fakeAuth) UserChangePassword(ctx context.Context, name string, password string) (*clientv3.AuthUserChangePasswordResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[name]
+	if !ok {
+		return nil, rpctypes.ErrUserNotFound
+	}
+	user.Password = []byte(password)
+	f.revision++
+	return &clientv3.AuthUserChangePasswordResponse{}, nil
+}
+
+func (f *fakeAuth) UserGrantRole(ctx context.Context, name string, role string) (*clientv3.AuthUserGrantRoleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[name]
+	if !ok {
+		return nil, rpctypes.ErrUserNotFound
+	}
+	if _, ok := f.roles[role]; !ok && role != "root" {
+		return nil, rpctypes.ErrRoleNotFound
+	}
+	for _, granted := range user.Roles {
+		if granted == role {
+			return &clientv3.AuthUserGrantRoleResponse{}, nil
+		}
+	}
+	user.Roles = append(user.Roles, role)
+	sort.Strings(user.Roles)
+	f.revision++
+	return &clientv3.AuthUserGrantRoleResponse{}, nil
+}
+
+func (f *fakeAuth) UserGet(ctx context.Context, name string) (*clientv3.AuthUserGetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[name]
+	if !ok {
+		return nil, rpctypes.ErrUserNotFound
+	}
+	return &clientv3.AuthUserGetResponse{Roles: append([]string{}, user.Roles...)}, nil
+}
+
+func (f *fakeAuth) UserList(ctx context.Context) (*clientv3.AuthUserListResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	users := []string{}
+	for name := range f.users {
+		users = append(users, name)
+	}
+	sort.Strings(users)
+	return &clientv3.AuthUserListResponse{Users: users}, nil
+}
+
+func (f *fakeAuth) UserRevokeRole(ctx context.Context, name string, role string) (*clientv3.AuthUserRevokeRoleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[name]
+	if !ok {
+		return nil, rpctypes.ErrUserNotFound
+	}
+	for i, granted := range user.Roles {
+		if granted == role {
+			user.Roles = append(user.Roles[:i], user.Roles[i+1:]...)
+			f.revision++
+			return &clientv3.AuthUserRevokeRoleResponse{}, nil
+		}
+	}
+	return nil, rpctypes.ErrRoleNotGranted
+}
+
+func (f *fakeAuth) RoleAdd(ctx context.Context, name string) (*clientv3.AuthRoleAddResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.roles[name]; ok {
+		return nil, rpctypes.ErrRoleAlreadyExist
+	}
+	f.roles[name] = &authpb.Role{Name: []byte(name)}
+	f.revision++
+	return &clientv3.AuthRoleAddResponse{}, nil
+}
+
+func (f *fakeAuth) RoleGrantPermission(ctx context.Context, name string, key, rangeEnd string, permType clientv3.PermissionType) (*clientv3.AuthRoleGrantPermissionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	role, ok := f.roles[name]
+	if !ok {
+		return nil, rpctypes.ErrRoleNotFound
+	}
+	f.revision++
+	for _, perm := range role.KeyPermission {
+		if bytes.Equal(perm.Key, []byte(key)) && bytes.Equal(perm.RangeEnd, []byte(rangeEnd)) {
+			perm.PermType = authpb.Permission_Type(permType)
+			return &clientv3.AuthRoleGrantPermissionResponse{}, nil
+		}
+	}
+	role.KeyPermission = append(role.KeyPermission, &authpb.Permission{
+		PermType: authpb.Permission_Type(permType),
+		Key:      []byte(key),
+		RangeEnd: []byte(rangeEnd),
+	})
+	return &clientv3.AuthRoleGrantPermissionResponse{}, nil
+}
+
+func (f *fakeAuth) RoleGet(ctx context.Context, name string) (*clientv3.AuthRoleGetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	role, ok := f.roles[name]
+	if !ok {
+		return nil, rpctypes.ErrRoleNotFound
+	}
+	perms := []*authpb.Permission{}
+	for _, perm := range role.KeyPermission {
+		copied := *perm
+		perms = append(perms, &copied)
+	}
+	return &clientv3.AuthRoleGetResponse{Perm: perms}, nil
+}
+
+func (f *fakeAuth) RoleList(ctx context.Context) (*clientv3.AuthRoleListResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	roles := []string{}
+	for name := range f.roles {
+		roles = append(roles, name)
+	}
+	sort.Strings(roles)
+	return &clientv3.AuthRoleListResponse{Roles: roles}, nil
+}
+
+func (f *fakeAuth) RoleRevokePermission(ctx context.Context, name string, key, rangeEnd string) (*clientv3.AuthRoleRevokePermissionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	role, ok := f.roles[name]
+	if !ok {
+		return nil, rpctypes.ErrRoleNotFound
+	}
+	for i, perm := range role.KeyPermission {
+		if bytes.Equal(perm.Key, []byte(key)) && bytes.Equal(perm.RangeEnd, []byte(rangeEnd)) {
+			role.KeyPermission = append(role.KeyPermission[:i], role.KeyPermission[i+1:]...)
+			f.revision++
+			return &clientv3.AuthRoleRevokePermissionResponse{}, nil
+		}
+	}
+	return nil, rpctypes.ErrPermissionNotGranted
+}
+
+func (f *fakeAuth) RoleDelete(ctx context.Context, name string) (*clientv3.AuthRoleDeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.roles[name]; !ok {
+		return nil, rpctypes.ErrRoleNotFound
+	}
+	delete(f.roles, name)
+	for _, user := range f.users {
+		for i, granted := range user.Roles {
+			if granted == name {
+				user.Roles = append(user.Roles[:i], user.Roles[i+1:]...)
+				break
+			}
+		}
+	}
+	f.revision++
+	return &clientv3.AuthRoleDeleteResponse{}, nil
+}
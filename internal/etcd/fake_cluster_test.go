@@ -0,0 +1,71 @@
+package etcd
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeCluster serves a member list, or err when set. Methods that are not
+// needed by the resources are left to the embedded interface.
+type fakeCluster struct {
+	clientv3.Cluster
+
+	members []*pb.Member
+	err     error
+}
+
+func (f *fakeCluster) MemberList(ctx context.Context) (*clientv3.MemberListResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &clientv3.MemberListResponse{Header: &pb.ResponseHeader{ClusterId: 0xcafe}, Members: f.members}, nil
+}
+
+func (f *fakeCluster) memberAdd(peerURLs []string, learner bool) (*clientv3.MemberAddResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	member := &pb.Member{ID: uint64(0x100 + len(f.members)), PeerURLs: peerURLs, IsLearner: learner}
+	f.members = append(f.members, member)
+	return &clientv3.MemberAddResponse{Header: &pb.ResponseHeader{}, Member: member, Members: f.members}, nil
+}
+
+func (f *fakeCluster) MemberAdd(ctx context.Context, peerURLs []string) (*clientv3.MemberAddResponse, error) {
+	return f.memberAdd(peerURLs, false)
+}
+
+func (f *fakeCluster) MemberAddAsLearner(ctx context.Context, peerURLs []string) (*clientv3.MemberAddResponse, error) {
+	return f.memberAdd(peerURLs, true)
+}
+
+func (f *fakeCluster) MemberRemove(ctx context.Context, id uint64) (*clientv3.MemberRemoveResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for i, member := range f.members {
+		if member.ID == id {
+			f.members = append(f.members[:i], f.members[i+1:]...)
+			return &clientv3.MemberRemoveResponse{Header: &pb.ResponseHeader{}, Members: f.members}, nil
+		}
+	}
+	return nil, rpctypes.ErrMemberNotFound
+}
+
+func (f *fakeCluster) MemberPromote(ctx context.Context, id uint64) (*clientv3.MemberPromoteResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, member := range f.members {
+		if member.ID == id {
+			if !member.IsLearner {
+				return nil, rpctypes.ErrMemberNotLearner
+			}
+			member.IsLearner = false
+			return &clientv3.MemberPromoteResponse{Header: &pb.ResponseHeader{}, Members: f.members}, nil
+		}
+	}
+	return nil, rpctypes.ErrMemberNotFound
+}
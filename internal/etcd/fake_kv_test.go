@@ -0,0 +1,536 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeKV is an in-memory clientv3.KV used to exercise the resources without
+// a running etcd cluster. It keeps a snapshot per revision so revision-pinned
+// reads and compaction behave like the real store.
+type fakeKV struct {
+	mu         sync.Mutex
+	rev        int64
+	compactRev int64
+	kvs        map[string]*mvccpb.KeyValue
+	history    map[int64]map[string]*mvccpb.KeyValue
+
+	// errs are returned, one per call, before any operation is applied.
+	errs []error
+
+	// maxTxnOps rejects larger transactions like --max-txn-ops when set,
+	// txns counts the committed transactions.
+	maxTxnOps int
+	txns      int
+
+	// gets records every read, to inspect the options it was made with.
+	gets []clientv3.Op
+
+	// events holds every write, pending the ones of the running operation
+	// until their revision is final.
+	events   []*clientv3.Event
+	pending  []*clientv3.Event
+	watchers map[*fakeWatch]struct{}
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{
+		rev:      1,
+		kvs:      map[string]*mvccpb.KeyValue{},
+		history:  map[int64]map[string]*mvccpb.KeyValue{1: {}},
+		watchers: map[*fakeWatch]struct{}{},
+	}
+}
+
+// newFakeClient returns an apiClient whose KV is backed by kv.
+func newFakeClient(kv *fakeKV) *apiClient {
+	return &apiClient{Client: &clientv3.Client{KV: kv}}
+}
+
+func (f *fakeKV) nextErr() error {
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakeKV) header() *pb.ResponseHeader {
+	return &pb.ResponseHeader{ClusterId: 1, MemberId: 1, Revision: f.rev}
+}
+
+func (f *fakeKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	resp, err := f.Do(ctx, clientv3.OpPut(key, val, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Put(), nil
+}
+
+func (f *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	resp, err := f.Do(ctx, clientv3.OpGet(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Get(), nil
+}
+
+func (f *fakeKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	resp, err := f.Do(ctx, clientv3.OpDelete(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Del(), nil
+}
+
+func (f *fakeKV) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.nextErr(); err != nil {
+		return nil, err
+	}
+	if rev <= f.compactRev {
+		return nil, rpctypes.ErrCompacted
+	}
+	if rev > f.rev {
+		return nil, rpctypes.ErrFutureRev
+	}
+	f.compactRev = rev
+	return &clientv3.CompactResponse{Header: f.header()}, nil
+}
+
+func (f *fakeKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return clientv3.OpResponse{}, err
+	}
+	if err := f.nextErr(); err != nil {
+		return clientv3.OpResponse{}, err
+	}
+	if op.IsGet() {
+		f.gets = append(f.gets, op)
+	}
+	resp, err := f.apply(op)
+	if err != nil {
+		return clientv3.OpResponse{}, err
+	}
+	f.commit(op.IsPut() || op.IsDelete())
+	f.publish()
+	switch {
+	case resp.GetResponseRange() != nil:
+		r := clientv3.GetResponse(*resp.GetResponseRange())
+		return r.OpResponse(), nil
+	case resp.GetResponsePut() != nil:
+		r := clientv3.PutResponse(*resp.GetResponsePut())
+		return r.OpResponse(), nil
+	default:
+		r := clientv3.DeleteResponse(*resp.GetResponseDeleteRange())
+		return r.OpResponse(), nil
+	}
+}
+
+func (f *fakeKV) Txn(ctx context.Context) clientv3.Txn {
+	return &fakeTxn{kv: f, ctx: ctx}
+}
+
+// commit records the current store as a new revision when it was written.
+func (f *fakeKV) commit(written bool) {
+	if !written {
+		return
+	}
+	snapshot := make(map[string]*mvccpb.KeyValue, len(f.kvs))
+	for k, v := range f.kvs {
+		snapshot[k] = v
+	}
+	f.history[f.rev] = snapshot
+}
+
+// opField reads an unexported clientv3.Op option, which the fake needs in
+// order to honour things like leases and limits.
+func opField(op clientv3.Op, name string) reflect.Value {
+	return reflect.ValueOf(op).FieldByName(name)
+}
+
+func inRange(key, start, end []byte) bool {
+	switch {
+	case len(end) == 0:
+		return bytes.Equal(key, start)
+	case bytes.Equal(end, []byte{0}):
+		return bytes.Compare(key, start) >= 0
+	default:
+		return bytes.Compare(key, start) >= 0 && bytes.Compare(key, end) < 0
+	}
+}
+
+func (f *fakeKV) rangeKeys(store map[string]*mvccpb.KeyValue, start, end []byte) []*mvccpb.KeyValue {
+	var kvs []*mvccpb.KeyValue
+	for k, v := range store {
+		if inRange([]byte(k), start, end) {
+			kvs = append(kvs, v)
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0 })
+	return kvs
+}
+
+// sortKeys sorts kvs already ordered by key by target, in order.
+func sortKeys(kvs []*mvccpb.KeyValue, target clientv3.SortTarget, order clientv3.SortOrder) {
+	less := func(i, j int) bool {
+		switch target {
+		case clientv3.SortByCreateRevision:
+			return kvs[i].CreateRevision < kvs[j].CreateRevision
+		case clientv3.SortByModRevision:
+			return kvs[i].ModRevision < kvs[j].ModRevision
+		case clientv3.SortByVersion:
+			return kvs[i].Version < kvs[j].Version
+		case clientv3.SortByValue:
+			return bytes.Compare(kvs[i].Value, kvs[j].Value) < 0
+		default:
+			return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0
+		}
+	}
+	if order == clientv3.SortDescend {
+		sort.SliceStable(kvs, func(i, j int) bool { return less(j, i) })
+		return
+	}
+	sort.SliceStable(kvs, less)
+}
+
+func (f *fakeKV) apply(op clientv3.Op) (*pb.ResponseOp, error) {
+	switch {
+	case op.IsGet():
+		store := f.kvs
+		if rev := op.Rev(); rev > 0 {
+			if rev < f.compactRev {
+				return nil, rpctypes.ErrCompacted
+			}
+			if rev > f.rev {
+				return nil, rpctypes.ErrFutureRev
+			}
+			for r := rev; r > 0; r-- {
+				if snapshot, ok := f.history[r]; ok {
+					store = snapshot
+					break
+				}
+			}
+		}
+		kvs := f.rangeKeys(store, op.KeyBytes(), op.RangeBytes())
+		if maxCreateRev := opField(op, "maxCreateRev").Int(); maxCreateRev > 0 {
+			filtered := []*mvccpb.KeyValue{}
+			for _, kv := range kvs {
+				if kv.CreateRevision <= maxCreateRev {
+					filtered = append(filtered, kv)
+				}
+			}
+			kvs = filtered
+		}
+		if s := opField(op, "sort"); !s.IsNil() {
+			sortKeys(kvs, clientv3.SortTarget(s.Elem().FieldByName("Target").Int()), clientv3.SortOrder(s.Elem().FieldByName("Order").Int()))
+		}
+		resp := &pb.RangeResponse{Header: f.header(), Count: int64(len(kvs))}
+		if limit := opField(op, "limit").Int(); limit > 0 && int64(len(kvs)) > limit {
+			kvs = kvs[:limit]
+			resp.More = true
+		}
+		if !op.IsCountOnly() {
+			resp.Kvs = kvs
+		}
+		return &pb.ResponseOp{Response: &pb.ResponseOp_ResponseRange{ResponseRange: resp}}, nil
+	case op.IsPut():
+		key := string(op.KeyBytes())
+		prev := f.kvs[key]
+		kv := &mvccpb.KeyValue{Key: op.KeyBytes(), Value: op.ValueBytes(), Lease: opField(op, "leaseID").Int()}
+		if opField(op, "ignoreValue").Bool() {
+			if prev == nil {
+				return nil, rpctypes.ErrKeyNotFound
+			}
+			kv.Value = prev.Value
+		}
+		f.rev++
+		kv.ModRevision = f.rev
+		kv.CreateRevision = f.rev
+		kv.Version = 1
+		if prev != nil {
+			kv.CreateRevision = prev.CreateRevision
+			kv.Version = prev.Version + 1
+		}
+		f.kvs[key] = kv
+		f.pending = append(f.pending, &clientv3.Event{Type: mvccpb.PUT, Kv: kv, PrevKv: prev})
+		resp := &pb.PutResponse{Header: f.header()}
+		if opField(op, "prevKV").Bool() {
+			resp.PrevKv = prev
+		}
+		return &pb.ResponseOp{Response: &pb.ResponseOp_ResponsePut{ResponsePut: resp}}, nil
+	default:
+		kvs := f.rangeKeys(f.kvs, op.KeyBytes(), op.RangeBytes())
+		if len(kvs) > 0 {
+			f.rev++
+		}
+		for _, kv := range kvs {
+			delete(f.kvs, string(kv.Key))
+			f.pending = append(f.pending, &clientv3.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: kv.Key}, PrevKv: kv})
+		}
+		resp := &pb.DeleteRangeResponse{Header: f.header(), Deleted: int64(len(kvs))}
+		if opField(op, "prevKV").Bool() {
+			resp.PrevKvs = kvs
+		}
+		return &pb.ResponseOp{Response: &pb.ResponseOp_ResponseDeleteRange{ResponseDeleteRange: resp}}, nil
+	}
+}
+
+// compare evaluates cmp like etcd: a ranged comparison holds when every key
+// in the range satisfies it, and an empty range compares a missing key.
+func (f *fakeKV) compare(cmp clientv3.Cmp) bool {
+	if len(cmp.RangeEnd) == 0 {
+		return compareKV(cmp, f.kvs[string(cmp.Key)])
+	}
+	kvs := f.rangeKeys(f.kvs, cmp.Key, cmp.RangeEnd)
+	if len(kvs) == 0 {
+		return cmp.Target != pb.Compare_VALUE && compareKV(cmp, nil)
+	}
+	for _, kv := range kvs {
+		if !compareKV(cmp, kv) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareKV(cmp clientv3.Cmp, kv *mvccpb.KeyValue) bool {
+	var result int
+	switch cmp.Target {
+	case pb.Compare_VALUE:
+		var value []byte
+		if kv != nil {
+			value = kv.Value
+		}
+		result = bytes.Compare(value, cmp.ValueBytes())
+	default:
+		var actual, expected int64
+		if kv != nil {
+			switch cmp.Target {
+			case pb.Compare_VERSION:
+				actual = kv.Version
+			case pb.Compare_CREATE:
+				actual = kv.CreateRevision
+			case pb.Compare_MOD:
+				actual = kv.ModRevision
+			case pb.Compare_LEASE:
+				actual = kv.Lease
+			}
+		}
+		switch u := cmp.TargetUnion.(type) {
+		case *pb.Compare_Version:
+			expected = u.Version
+		case *pb.Compare_CreateRevision:
+			expected = u.CreateRevision
+		case *pb.Compare_ModRevision:
+			expected = u.ModRevision
+		case *pb.Compare_Lease:
+			expected = u.Lease
+		}
+		switch {
+		case actual < expected:
+			result = -1
+		case actual > expected:
+			result = 1
+		}
+	}
+	switch cmp.Result {
+	case pb.Compare_EQUAL:
+		return result == 0
+	case pb.Compare_NOT_EQUAL:
+		return result != 0
+	case pb.Compare_GREATER:
+		return result > 0
+	default:
+		return result < 0
+	}
+}
+
+type fakeTxn struct {
+	kv   *fakeKV
+	ctx  context.Context
+	cmps []clientv3.Cmp
+	then []clientv3.Op
+	els  []clientv3.Op
+}
+
+func (t *fakeTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	t.cmps = append(t.cmps, cs...)
+	return t
+}
+
+func (t *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.then = append(t.then, ops...)
+	return t
+}
+
+func (t *fakeTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	t.els = append(t.els, ops...)
+	return t
+}
+
+func (t *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	f := t.kv
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := f.nextErr(); err != nil {
+		return nil, err
+	}
+	succeeded := true
+	for _, cmp := range t.cmps {
+		if !f.compare(cmp) {
+			succeeded = false
+			break
+		}
+	}
+	ops := t.then
+	if !succeeded {
+		ops = t.els
+	}
+	if f.maxTxnOps > 0 && len(ops) > f.maxTxnOps {
+		return nil, rpctypes.ErrTooManyOps
+	}
+	f.txns++
+	// A transaction is a single revision no matter how many writes it holds.
+	startRev := f.rev
+	resp := &clientv3.TxnResponse{Succeeded: succeeded}
+	written := false
+	for _, op := range ops {
+		r, err := f.apply(op)
+		if err != nil {
+			return nil, err
+		}
+		if f.rev != startRev {
+			written = true
+			f.rev = startRev + 1
+			for _, kv := range f.kvs {
+				if kv.ModRevision > f.rev {
+					kv.ModRevision = f.rev
+				}
+				if kv.CreateRevision > f.rev {
+					kv.CreateRevision = f.rev
+				}
+			}
+		}
+		resp.Responses = append(resp.Responses, r)
+	}
+	resp.Header = f.header()
+	f.commit(written)
+	f.publish()
+	return resp, nil
+}
+
+// assertKeyValue fails the test unless key holds value in kv.
+func assertKeyValue(test *testing.T, kv *fakeKV, key, value string) {
+	test.Helper()
+	response, err := kv.Get(context.Background(), key)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if len(response.Kvs) == 0 {
+		test.Fatalf("expected %q to exist", key)
+	}
+	if got := string(response.Kvs[0].Value); got != value {
+		test.Fatalf("%s: expected %q, got %q", key, value, got)
+	}
+}
+
+// publish stamps the pending events with the committed revision and sends
+// them to the matching watchers.
+func (f *fakeKV) publish() {
+	for _, event := range f.pending {
+		if event.Type == mvccpb.DELETE {
+			event.Kv.ModRevision = f.rev
+		}
+		f.events = append(f.events, event)
+		for watch := range f.watchers {
+			watch.send(event)
+		}
+	}
+	f.pending = nil
+}
+
+type fakeWatch struct {
+	start, end   []byte
+	filterPut    bool
+	filterDelete bool
+	ch           chan clientv3.WatchResponse
+}
+
+func (w *fakeWatch) send(event *clientv3.Event) {
+	if !inRange(event.Kv.Key, w.start, w.end) {
+		return
+	}
+	if (event.Type == mvccpb.PUT && w.filterPut) || (event.Type == mvccpb.DELETE && w.filterDelete) {
+		return
+	}
+	w.ch <- clientv3.WatchResponse{Events: []*clientv3.Event{event}}
+}
+
+// fakeWatcher is a clientv3.Watcher streaming the writes of a fakeKV.
+type fakeWatcher struct {
+	kv *fakeKV
+}
+
+// newFakeWatchClient returns an apiClient whose KV and Watcher are backed by kv.
+func newFakeWatchClient(kv *fakeKV) *apiClient {
+	client := newFakeClient(kv)
+	client.Watcher = &fakeWatcher{kv: kv}
+	return client
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	op := clientv3.OpGet(key, opts...)
+	watch := &fakeWatch{
+		start:        op.KeyBytes(),
+		end:          op.RangeBytes(),
+		filterPut:    opField(op, "filterPut").Bool(),
+		filterDelete: opField(op, "filterDelete").Bool(),
+		ch:           make(chan clientv3.WatchResponse, 1024),
+	}
+
+	f := w.kv
+	f.mu.Lock()
+	if rev := op.Rev(); rev > 0 {
+		for _, event := range f.events {
+			if event.Kv.ModRevision >= rev {
+				watch.send(event)
+			}
+		}
+	}
+	f.watchers[watch] = struct{}{}
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		delete(f.watchers, watch)
+		f.mu.Unlock()
+		close(watch.ch)
+	}()
+	return watch.ch
+}
+
+func (w *fakeWatcher) RequestProgress(ctx context.Context) error {
+	return nil
+}
+
+func (w *fakeWatcher) Close() error {
+	return nil
+}
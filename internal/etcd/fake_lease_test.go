@@ -0,0 +1,150 @@
+package etcd
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type fakeLeaseEntry struct {
+	ttl        int64
+	grantedTTL int64
+	keepAlives int
+}
+
+// fakeLease is an in-memory clientv3.Lease bound to a fakeKV, so revoking or
+// expiring a lease deletes the keys attached to it like etcd does.
+type fakeLease struct {
+	mu     sync.Mutex
+	kv     *fakeKV
+	nextID clientv3.LeaseID
+	leases map[clientv3.LeaseID]*fakeLeaseEntry
+}
+
+func newFakeLease(kv *fakeKV) *fakeLease {
+	return &fakeLease{kv: kv, nextID: 100, leases: map[clientv3.LeaseID]*fakeLeaseEntry{}}
+}
+
+// newFakeLeaseClient returns an apiClient backed by kv and lease. Its
+// keep-alive manager runs until the test closes it.
+func newFakeLeaseClient(kv *fakeKV, lease *fakeLease) *apiClient {
+	client := newFakeClient(kv)
+	client.Lease = lease
+	client.keepAlives = newKeepAliveManager(context.Background(), lease)
+	return client
+}
+
+// expire drops the lease and its attached keys as if its TTL elapsed.
+func (f *fakeLease) expire(id clientv3.LeaseID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drop(id)
+}
+
+func (f *fakeLease) drop(id clientv3.LeaseID) {
+	delete(f.leases, id)
+	f.kv.mu.Lock()
+	defer f.kv.mu.Unlock()
+	for key, kv := range f.kv.kvs {
+		if kv.Lease == int64(id) {
+			delete(f.kv.kvs, key)
+		}
+	}
+}
+
+func (f *fakeLease) attachedKeys(id clientv3.LeaseID) [][]byte {
+	f.kv.mu.Lock()
+	defer f.kv.mu.Unlock()
+	keys := [][]byte{}
+	for _, kv := range f.kv.rangeKeys(f.kv.kvs, []byte{0}, []byte{0}) {
+		if kv.Lease == int64(id) {
+			keys = append(keys, kv.Key)
+		}
+	}
+	return keys
+}
+
+func (f *fakeLease) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	f.leases[f.nextID] = &fakeLeaseEntry{ttl: ttl, grantedTTL: ttl}
+	return &clientv3.LeaseGrantResponse{ID: f.nextID, TTL: ttl}, nil
+}
+
+func (f *fakeLease) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.leases[id]; !ok {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	f.drop(id)
+	return &clientv3.LeaseRevokeResponse{}, nil
+}
+
+func (f *fakeLease) TimeToLive(ctx context.Context, id clientv3.LeaseID, opts ...clientv3.LeaseOption) (*clientv3.LeaseTimeToLiveResponse, error) {
+	f.mu.Lock()
+	lease, ok := f.leases[id]
+	f.mu.Unlock()
+	if !ok {
+		return &clientv3.LeaseTimeToLiveResponse{ID: id, TTL: -1}, nil
+	}
+	response := &clientv3.LeaseTimeToLiveResponse{
+		ID:         id,
+		TTL:        lease.ttl,
+		GrantedTTL: lease.grantedTTL,
+	}
+	op := clientv3.LeaseOp{}
+	for _, opt := range opts {
+		opt(&op)
+	}
+	if reflect.ValueOf(op).FieldByName("attachedKeys").Bool() {
+		response.Keys = f.attachedKeys(id)
+	}
+	return response, nil
+}
+
+func (f *fakeLease) Leases(ctx context.Context) (*clientv3.LeaseLeasesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	leases := []clientv3.LeaseStatus{}
+	for id := range f.leases {
+		leases = append(leases, clientv3.LeaseStatus{ID: id})
+	}
+	return &clientv3.LeaseLeasesResponse{Leases: leases}, nil
+}
+
+func (f *fakeLease) KeepAliveOnce(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	lease, ok := f.leases[id]
+	if !ok {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	lease.ttl = lease.grantedTTL
+	lease.keepAlives++
+	return &clientv3.LeaseKeepAliveResponse{ID: id, TTL: lease.ttl}, nil
+}
+
+// KeepAlive refreshes the lease once and keeps the channel open until ctx is
+// done, which is enough for callers that only drain it.
+func (f *fakeLease) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	resp, err := f.KeepAliveOnce(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *clientv3.LeaseKeepAliveResponse, 1)
+	ch <- resp
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func (f *fakeLease) Close() error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeMaintenance serves canned per-endpoint status responses. Methods that
+// are not needed by the resources are left to the embedded interface.
+type fakeMaintenance struct {
+	clientv3.Maintenance
+
+	status map[string]*clientv3.StatusResponse
+	alarms []*pb.AlarmMember
+
+	// defragmented are the endpoints defragmented, in order.
+	defragmented []string
+
+	// snapshot is streamed by Snapshot, followed by snapshotErr if set.
+	snapshot    []byte
+	snapshotErr error
+	// snapshotStalled streams nothing until the context of Snapshot is done.
+	snapshotStalled bool
+}
+
+func newFakeMaintenance() *fakeMaintenance {
+	return &fakeMaintenance{status: map[string]*clientv3.StatusResponse{}}
+}
+
+// addEndpoint registers a healthy endpoint with the given database size.
+func (f *fakeMaintenance) addEndpoint(endpoint string, dbSize int64) {
+	f.status[endpoint] = &clientv3.StatusResponse{
+		Header:  &pb.ResponseHeader{ClusterId: 1, MemberId: uint64(len(f.status) + 1)},
+		Version: "3.5.0",
+		DbSize:  dbSize,
+		Leader:  1,
+	}
+}
+
+func (f *fakeMaintenance) Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	status, ok := f.status[endpoint]
+	if !ok {
+		return nil, fmt.Errorf("dial tcp %s: connect: connection refused", endpoint)
+	}
+	return status, nil
+}
+
+func (f *fakeMaintenance) AlarmList(ctx context.Context) (*clientv3.AlarmResponse, error) {
+	return &clientv3.AlarmResponse{Header: &pb.ResponseHeader{}, Alarms: f.alarms}, nil
+}
+
+func (f *fakeMaintenance) AlarmDisarm(ctx context.Context, m *clientv3.AlarmMember) (*clientv3.AlarmResponse, error) {
+	response := &clientv3.AlarmResponse{Header: &pb.ResponseHeader{}}
+	remaining := []*pb.AlarmMember{}
+	for _, alarm := range f.alarms {
+		if alarm.MemberID == m.MemberID && alarm.Alarm == m.Alarm {
+			response.Alarms = append(response.Alarms, alarm)
+			continue
+		}
+		remaining = append(remaining, alarm)
+	}
+	f.alarms = remaining
+	return response, nil
+}
+
+func (f *fakeMaintenance) Defragment(ctx context.Context, endpoint string) (*clientv3.DefragmentResponse, error) {
+	if _, ok := f.status[endpoint]; !ok {
+		return nil, fmt.Errorf("dial tcp %s: connect: connection refused", endpoint)
+	}
+	f.defragmented = append(f.defragmented, endpoint)
+	return &clientv3.DefragmentResponse{Header: &pb.ResponseHeader{}}, nil
+}
+
+// setSnapshot serves db as a snapshot, followed by its sha256 like etcd does.
+func (f *fakeMaintenance) setSnapshot(db []byte) {
+	sum := sha256.Sum256(db)
+	f.snapshot = append(append([]byte{}, db...), sum[:]...)
+}
+
+func (f *fakeMaintenance) Snapshot(ctx context.Context) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var reader io.Reader = bytes.NewReader(f.snapshot)
+	if f.snapshotStalled {
+		reader = &stalledReader{ctx}
+	}
+	if f.snapshotErr != nil {
+		reader = io.MultiReader(reader, &errReader{f.snapshotErr})
+	}
+	return ioutil.NopCloser(reader), nil
+}
+
+type stalledReader struct {
+	ctx context.Context
+}
+
+func (r *stalledReader) Read(p []byte) (int, error) {
+	<-r.ctx.Done()
+	return 0, r.ctx.Err()
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,52 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// grpcDebugLogger logs every etcd RPC of the provider when debug_grpc is
+// enabled, for debugging what the provider actually sends to the cluster.
+type grpcDebugLogger struct {
+	// payloads also logs the request and response messages, which carry the
+	// keys and values read and written.
+	payloads bool
+}
+
+// unaryInterceptor runs inside the etcd client's retry interceptor, so every
+// attempt of a retried RPC is logged.
+func (l *grpcDebugLogger) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	fields := map[string]interface{}{
+		"method":   method,
+		"duration": time.Since(start),
+		"code":     status.Code(err).String(),
+	}
+	if l.payloads {
+		fields["request"] = grpcPayload(method, req)
+		if err == nil {
+			fields["response"] = grpcPayload(method, reply)
+		}
+	}
+	logFields(ctx, "TRACE", "etcd rpc", fields)
+	return err
+}
+
+// grpcPayload formats a message for the RPC log. Messages of the Auth
+// service carry passwords and tokens and are never logged.
+func grpcPayload(method string, message interface{}) string {
+	if strings.HasPrefix(method, "/etcdserverpb.Auth/") {
+		return "<redacted>"
+	}
+	if stringer, ok := message.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%v", message)
+}
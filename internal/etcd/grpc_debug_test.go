@@ -0,0 +1,67 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDebugGRPCLogsGet(test *testing.T) {
+	endpoint := serveKV(test)
+
+	for _, tc := range []struct {
+		name     string
+		payloads bool
+	}{
+		{"without payloads", false},
+		{"with payloads", true},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+				"endpoints":           []interface{}{endpoint},
+				"check_health":        false,
+				"debug_grpc":          true,
+				"debug_grpc_payloads": tc.payloads,
+			})
+			meta, diags := configure(context.Background(), d)
+			if diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+			client := meta.(*apiClient)
+			defer client.Close()
+
+			ctx := client.startOperation(context.Background())
+			lines := captureLogs(func() {
+				if _, err := client.Get(ctx, "/app/secret-name"); err != nil {
+					test.Fatalf("err: %s", err)
+				}
+			})
+
+			var rpc string
+			for _, line := range lines {
+				if strings.Contains(line, "etcd rpc:") {
+					rpc = line
+				}
+			}
+			if rpc == "" {
+				test.Fatalf("expected a log line for the Get, got:\n%s", strings.Join(lines, "\n"))
+			}
+			for _, expected := range []string{"[TRACE]", "[operation_id=" + operationID(ctx) + "]", `method="/etcdserverpb.KV/Range"`, `code="OK"`, "duration="} {
+				if !strings.Contains(rpc, expected) {
+					test.Fatalf("expected %s in the log line, got %q", expected, rpc)
+				}
+			}
+			if strings.Contains(rpc, "secret-name") != tc.payloads {
+				test.Fatalf("expected the payload to be logged only with debug_grpc_payloads, got %q", rpc)
+			}
+		})
+	}
+}
+
+func TestDebugGRPCPayloadsRedactAuth(test *testing.T) {
+	if payload := grpcPayload("/etcdserverpb.Auth/Authenticate", "password"); payload != "<redacted>" {
+		test.Fatalf("expected the Auth payload to be redacted, got %q", payload)
+	}
+}
@@ -0,0 +1,339 @@
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsonSchemaAnnotations are keywords that do not constrain the document.
+var jsonSchemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "format": true, "readOnly": true, "writeOnly": true,
+}
+
+// jsonSchemaKeywords are the validation keywords of JSON Schema draft 7 the
+// validator implements. Schemas using any other keyword, such as $ref, are
+// rejected rather than partially checked.
+var jsonSchemaKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true, "minProperties": true, "maxProperties": true,
+	"items": true, "minItems": true, "maxItems": true, "uniqueItems": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true, "multipleOf": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true,
+}
+
+// supportedJSONSchemaKeywords lists jsonSchemaKeywords for error messages.
+func supportedJSONSchemaKeywords() string {
+	keywords := make([]string, 0, len(jsonSchemaKeywords))
+	for keyword := range jsonSchemaKeywords {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	return strings.Join(keywords, ", ")
+}
+
+// parseJSONSchema parses a JSON Schema and checks it only uses supported
+// keywords with well-formed arguments.
+func parseJSONSchema(text string) (interface{}, error) {
+	var jsonSchema interface{}
+	if err := json.Unmarshal([]byte(text), &jsonSchema); err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %v", err)
+	}
+	if err := checkJSONSchema(jsonSchema, ""); err != nil {
+		return nil, err
+	}
+	return jsonSchema, nil
+}
+
+func validateJSONSchemaText(v interface{}, k string) ([]string, []error) {
+	if _, err := parseJSONSchema(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q: %v", k, err)}
+	}
+	return nil, nil
+}
+
+func checkJSONSchema(jsonSchema interface{}, path string) error {
+	if _, ok := jsonSchema.(bool); ok {
+		return nil
+	}
+	object, ok := jsonSchema.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("schema at %s must be an object or a boolean", pointer(path))
+	}
+	for keyword, argument := range object {
+		if jsonSchemaAnnotations[keyword] {
+			continue
+		}
+		if !jsonSchemaKeywords[keyword] {
+			return fmt.Errorf("schema at %s uses the unsupported keyword %q, only a subset of JSON Schema draft 7 is supported, described in the etcd_schema_validation documentation: %s and annotations", pointer(path), keyword, supportedJSONSchemaKeywords())
+		}
+		var err error
+		switch keyword {
+		case "properties":
+			properties, ok := argument.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("properties at %s must be an object", pointer(path))
+			}
+			for name, property := range properties {
+				if err = checkJSONSchema(property, path+"/properties/"+escapePointer(name)); err != nil {
+					break
+				}
+			}
+		case "additionalProperties", "items", "not":
+			if _, ok := argument.([]interface{}); ok && keyword == "items" {
+				return fmt.Errorf("items at %s must be a single schema, tuple validation is not supported", pointer(path))
+			}
+			err = checkJSONSchema(argument, path+"/"+keyword)
+		case "allOf", "anyOf", "oneOf":
+			schemas, ok := argument.([]interface{})
+			if !ok || len(schemas) == 0 {
+				return fmt.Errorf("%s at %s must be a non-empty array of schemas", keyword, pointer(path))
+			}
+			for i, s := range schemas {
+				if err = checkJSONSchema(s, fmt.Sprintf("%s/%s/%d", path, keyword, i)); err != nil {
+					break
+				}
+			}
+		case "pattern":
+			pattern, ok := argument.(string)
+			if !ok {
+				return fmt.Errorf("pattern at %s must be a string", pointer(path))
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("pattern at %s is invalid: %v, patterns use the Go RE2 syntax rather than ECMA-262, without lookarounds or backreferences", pointer(path), err)
+			}
+		case "required":
+			if _, ok := argument.([]interface{}); !ok {
+				return fmt.Errorf("required at %s must be an array of property names", pointer(path))
+			}
+		case "enum":
+			if _, ok := argument.([]interface{}); !ok {
+				return fmt.Errorf("enum at %s must be an array", pointer(path))
+			}
+		case "type":
+			if _, ok := argument.(string); !ok {
+				if _, ok := argument.([]interface{}); !ok {
+					return fmt.Errorf("type at %s must be a string or an array of strings", pointer(path))
+				}
+			}
+		case "minProperties", "maxProperties", "minItems", "maxItems", "minLength", "maxLength",
+			"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			if _, ok := argument.(float64); !ok {
+				return fmt.Errorf("%s at %s must be a number", keyword, pointer(path))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateJSONDocument returns the violations of jsonSchema by document, each
+// prefixed with the JSON pointer of the offending value.
+func validateJSONDocument(jsonSchema interface{}, document interface{}, path string) []string {
+	if allowed, ok := jsonSchema.(bool); ok {
+		if !allowed {
+			return []string{fmt.Sprintf("%s: no value is allowed", pointer(path))}
+		}
+		return nil
+	}
+	object := jsonSchema.(map[string]interface{})
+	violation := func(format string, args ...interface{}) string {
+		return fmt.Sprintf("%s: %s", pointer(path), fmt.Sprintf(format, args...))
+	}
+
+	errs := []string{}
+	if types, ok := object["type"]; ok {
+		names := []string{}
+		if name, ok := types.(string); ok {
+			names = append(names, name)
+		} else {
+			for _, name := range types.([]interface{}) {
+				names = append(names, fmt.Sprint(name))
+			}
+		}
+		matched := false
+		for _, name := range names {
+			if jsonType(document) == name || (name == "number" && jsonType(document) == "integer") {
+				matched = true
+			}
+		}
+		if !matched {
+			// Further keywords would only repeat the type mismatch.
+			return []string{violation("expected %s, got %s", strings.Join(names, " or "), jsonType(document))}
+		}
+	}
+	if enum, ok := object["enum"]; ok {
+		found := false
+		for _, value := range enum.([]interface{}) {
+			if reflect.DeepEqual(value, document) {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, violation("value is not one of the enum values"))
+		}
+	}
+	if value, ok := object["const"]; ok && !reflect.DeepEqual(value, document) {
+		errs = append(errs, violation("value does not equal the const value"))
+	}
+
+	switch value := document.(type) {
+	case map[string]interface{}:
+		if required, ok := object["required"]; ok {
+			for _, name := range required.([]interface{}) {
+				if _, ok := value[fmt.Sprint(name)]; !ok {
+					errs = append(errs, violation("missing required property %q", name))
+				}
+			}
+		}
+		properties, _ := object["properties"].(map[string]interface{})
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propertyPath := path + "/" + escapePointer(name)
+			if property, ok := properties[name]; ok {
+				errs = append(errs, validateJSONDocument(property, value[name], propertyPath)...)
+			} else if additional, ok := object["additionalProperties"]; ok {
+				if allowed, ok := additional.(bool); ok && !allowed {
+					errs = append(errs, violation("additional property %q is not allowed", name))
+				} else {
+					errs = append(errs, validateJSONDocument(additional, value[name], propertyPath)...)
+				}
+			}
+		}
+		if min, ok := object["minProperties"]; ok && float64(len(value)) < min.(float64) {
+			errs = append(errs, violation("expected at least %v properties, got %d", min, len(value)))
+		}
+		if max, ok := object["maxProperties"]; ok && float64(len(value)) > max.(float64) {
+			errs = append(errs, violation("expected at most %v properties, got %d", max, len(value)))
+		}
+	case []interface{}:
+		if items, ok := object["items"]; ok {
+			for i, item := range value {
+				errs = append(errs, validateJSONDocument(items, item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+		if min, ok := object["minItems"]; ok && float64(len(value)) < min.(float64) {
+			errs = append(errs, violation("expected at least %v items, got %d", min, len(value)))
+		}
+		if max, ok := object["maxItems"]; ok && float64(len(value)) > max.(float64) {
+			errs = append(errs, violation("expected at most %v items, got %d", max, len(value)))
+		}
+		if unique, ok := object["uniqueItems"].(bool); ok && unique {
+			for i := range value {
+				for j := i + 1; j < len(value); j++ {
+					if reflect.DeepEqual(value[i], value[j]) {
+						errs = append(errs, violation("items %d and %d are equal", i, j))
+					}
+				}
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(value))
+		if min, ok := object["minLength"]; ok && length < min.(float64) {
+			errs = append(errs, violation("expected at least %v characters, got %v", min, length))
+		}
+		if max, ok := object["maxLength"]; ok && length > max.(float64) {
+			errs = append(errs, violation("expected at most %v characters, got %v", max, length))
+		}
+		if pattern, ok := object["pattern"]; ok && !regexp.MustCompile(pattern.(string)).MatchString(value) {
+			errs = append(errs, violation("%q does not match the pattern %q", value, pattern))
+		}
+	case float64:
+		if min, ok := object["minimum"]; ok && value < min.(float64) {
+			errs = append(errs, violation("%v is less than the minimum %v", value, min))
+		}
+		if max, ok := object["maximum"]; ok && value > max.(float64) {
+			errs = append(errs, violation("%v is greater than the maximum %v", value, max))
+		}
+		if min, ok := object["exclusiveMinimum"]; ok && value <= min.(float64) {
+			errs = append(errs, violation("%v is not greater than the exclusive minimum %v", value, min))
+		}
+		if max, ok := object["exclusiveMaximum"]; ok && value >= max.(float64) {
+			errs = append(errs, violation("%v is not less than the exclusive maximum %v", value, max))
+		}
+		if factor, ok := object["multipleOf"]; ok {
+			if quotient := value / factor.(float64); quotient != math.Trunc(quotient) {
+				errs = append(errs, violation("%v is not a multiple of %v", value, factor))
+			}
+		}
+	}
+
+	if schemas, ok := object["allOf"]; ok {
+		for _, s := range schemas.([]interface{}) {
+			errs = append(errs, validateJSONDocument(s, document, path)...)
+		}
+	}
+	if schemas, ok := object["anyOf"]; ok {
+		matched := 0
+		for _, s := range schemas.([]interface{}) {
+			if len(validateJSONDocument(s, document, path)) == 0 {
+				matched++
+			}
+		}
+		if matched == 0 {
+			errs = append(errs, violation("value does not match any schema of anyOf"))
+		}
+	}
+	if schemas, ok := object["oneOf"]; ok {
+		matched := 0
+		for _, s := range schemas.([]interface{}) {
+			if len(validateJSONDocument(s, document, path)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			errs = append(errs, violation("value matches %d schemas of oneOf instead of exactly one", matched))
+		}
+	}
+	if not, ok := object["not"]; ok && len(validateJSONDocument(not, document, path)) == 0 {
+		errs = append(errs, violation("value matches the schema of not"))
+	}
+	return errs
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value, integer for
+// numbers without a fractional part.
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// pointer formats a JSON pointer for messages, / for the document root.
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,124 @@
+package etcd
+
+import (
+	"context"
+	"sync"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type keepAliveLease struct {
+	cancel context.CancelFunc
+	ttl    int64
+	lost   bool
+
+	// owners are the resources that asked for the lease to be kept alive,
+	// it is kept alive until the last of them removes it.
+	owners map[string]bool
+}
+
+// keepAliveManager keeps leases alive in the background for as long as the
+// provider runs. Every lease is refreshed by its own clientv3 keep-alive
+// stream, all of them are stopped together when ctx is done or close is
+// called.
+type keepAliveManager struct {
+	ctx   context.Context
+	lease clientv3.Lease
+
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	leases map[clientv3.LeaseID]*keepAliveLease
+}
+
+func newKeepAliveManager(ctx context.Context, lease clientv3.Lease) *keepAliveManager {
+	return &keepAliveManager{
+		ctx:    ctx,
+		lease:  lease,
+		leases: map[clientv3.LeaseID]*keepAliveLease{},
+	}
+}
+
+// add starts keeping id alive on behalf of owner. A lease already kept
+// alive only records the new owner.
+func (m *keepAliveManager) add(id clientv3.LeaseID, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	owners := map[string]bool{}
+	if state, ok := m.leases[id]; ok {
+		state.owners[owner] = true
+		if !state.lost {
+			return nil
+		}
+		owners = state.owners
+	}
+	owners[owner] = true
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	responses, err := m.lease.KeepAlive(ctx, id)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	state := &keepAliveLease{cancel: cancel, owners: owners}
+	m.leases[id] = state
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for response := range responses {
+			m.mu.Lock()
+			state.ttl = response.TTL
+			m.mu.Unlock()
+		}
+		// The channel closes when the lease expired or could not be
+		// refreshed anymore, unless we stopped it ourselves.
+		if ctx.Err() == nil {
+			m.mu.Lock()
+			state.lost = true
+			m.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// remove drops owner from the owners of id, and stops keeping it alive
+// without revoking it when no owner is left.
+func (m *keepAliveManager) remove(id clientv3.LeaseID, owner string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.leases[id]
+	if !ok {
+		return
+	}
+	delete(state.owners, owner)
+	if len(state.owners) == 0 {
+		state.cancel()
+		delete(m.leases, id)
+	}
+}
+
+// status reports the last TTL seen for id and whether it was lost.
+func (m *keepAliveManager) status(id clientv3.LeaseID) (ttl int64, lost bool, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.leases[id]
+	if !ok {
+		return 0, false, false
+	}
+	return state.ttl, state.lost, true
+}
+
+// close stops every keep-alive and waits for them to finish.
+func (m *keepAliveManager) close() {
+	m.mu.Lock()
+	for id, state := range m.leases {
+		state.cancel()
+		delete(m.leases, id)
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+}
@@ -0,0 +1,27 @@
+package etcd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyValidationRules are the accepted values of the key_validation provider
+// option.
+var keyValidationRules = []string{"none", "no_trailing_slash", "printable_ascii"}
+
+// validateKey checks key against a key_validation rule.
+func validateKey(rule, key string) error {
+	switch rule {
+	case "no_trailing_slash":
+		if strings.HasSuffix(key, "/") {
+			return fmt.Errorf("key %q must not end with a slash", key)
+		}
+	case "printable_ascii":
+		for i := 0; i < len(key); i++ {
+			if key[i] < 0x20 || key[i] > 0x7e {
+				return fmt.Errorf("key %q must only hold printable ASCII characters, found byte 0x%02x at offset %d", key, key[i], i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestValidateKey(test *testing.T) {
+	for _, tc := range []struct {
+		rule  string
+		key   string
+		valid bool
+	}{
+		{"none", "/app/", true},
+		{"none", "/app/\x00name", true},
+		{"no_trailing_slash", "/app/name", true},
+		{"no_trailing_slash", "/app/", false},
+		{"no_trailing_slash", "/", false},
+		{"printable_ascii", "/app/name-1_~", true},
+		{"printable_ascii", "/app/na me", true},
+		{"printable_ascii", "/app/\tname", false},
+		{"printable_ascii", "/app/\x7f", false},
+		{"printable_ascii", "/app/é", false},
+	} {
+		if err := validateKey(tc.rule, tc.key); tc.valid != (err == nil) {
+			test.Fatalf("%s %q: expected valid=%v, got %v", tc.rule, tc.key, tc.valid, err)
+		}
+	}
+}
+
+func TestKvResourceKeyValidation(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.keyValidation = "no_trailing_slash"
+
+	_, err := KvResource().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":   "/app/",
+		"value": "passbase",
+	}), client)
+	if err == nil || !strings.Contains(err.Error(), "must not end with a slash") {
+		test.Fatalf("expected the key to be rejected at plan time, got %v", err)
+	}
+
+	if _, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "passbase",
+	}, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+}
@@ -0,0 +1,120 @@
+package etcd
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// leaderRetryDelay is how long a request waits for the leader election to
+// complete before it is retried.
+var leaderRetryDelay = 500 * time.Millisecond
+
+// leaderRetryKV retries the requests etcd rejected because the cluster has
+// no leader, up to retries times. These requests were never proposed to the
+// cluster, so retrying writes cannot apply them twice.
+type leaderRetryKV struct {
+	clientv3.KV
+	retries int
+}
+
+// applyLeaderRetry retries the key-value requests of client during leader
+// elections.
+func applyLeaderRetry(client *clientv3.Client, retries int) {
+	if retries == 0 {
+		return
+	}
+	client.KV = &leaderRetryKV{KV: client.KV, retries: retries}
+}
+
+func (kv *leaderRetryKV) retry(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt <= kv.retries && isLeaderElection(err); attempt++ {
+		logf(ctx, "INFO", "etcd leader election in progress, retrying in %s (attempt %d of %d): %v", leaderRetryDelay, attempt, kv.retries, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(leaderRetryDelay):
+		}
+		err = fn()
+	}
+	return err
+}
+
+func (kv *leaderRetryKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (response *clientv3.PutResponse, err error) {
+	err = kv.retry(ctx, func() error {
+		response, err = kv.KV.Put(ctx, key, val, opts...)
+		return err
+	})
+	return response, err
+}
+
+func (kv *leaderRetryKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (response *clientv3.GetResponse, err error) {
+	err = kv.retry(ctx, func() error {
+		response, err = kv.KV.Get(ctx, key, opts...)
+		return err
+	})
+	return response, err
+}
+
+func (kv *leaderRetryKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (response *clientv3.DeleteResponse, err error) {
+	err = kv.retry(ctx, func() error {
+		response, err = kv.KV.Delete(ctx, key, opts...)
+		return err
+	})
+	return response, err
+}
+
+func (kv *leaderRetryKV) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (response *clientv3.CompactResponse, err error) {
+	err = kv.retry(ctx, func() error {
+		response, err = kv.KV.Compact(ctx, rev, opts...)
+		return err
+	})
+	return response, err
+}
+
+func (kv *leaderRetryKV) Do(ctx context.Context, op clientv3.Op) (response clientv3.OpResponse, err error) {
+	err = kv.retry(ctx, func() error {
+		response, err = kv.KV.Do(ctx, op)
+		return err
+	})
+	return response, err
+}
+
+func (kv *leaderRetryKV) Txn(ctx context.Context) clientv3.Txn {
+	return &leaderRetryTxn{kv: kv, ctx: ctx}
+}
+
+// leaderRetryTxn records the transaction so it can be built again for every
+// attempt, a clientv3.Txn can only be committed once.
+type leaderRetryTxn struct {
+	kv      *leaderRetryKV
+	ctx     context.Context
+	cmps    []clientv3.Cmp
+	thenOps []clientv3.Op
+	elseOps []clientv3.Op
+}
+
+func (txn *leaderRetryTxn) If(cmps ...clientv3.Cmp) clientv3.Txn {
+	txn.cmps = append(txn.cmps, cmps...)
+	return txn
+}
+
+func (txn *leaderRetryTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.thenOps = append(txn.thenOps, ops...)
+	return txn
+}
+
+func (txn *leaderRetryTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.elseOps = append(txn.elseOps, ops...)
+	return txn
+}
+
+func (txn *leaderRetryTxn) Commit() (response *clientv3.TxnResponse, err error) {
+	err = txn.kv.retry(txn.ctx, func() error {
+		response, err = txn.kv.KV.Txn(txn.ctx).If(txn.cmps...).Then(txn.thenOps...).Else(txn.elseOps...).Commit()
+		return err
+	})
+	return response, err
+}
@@ -0,0 +1,49 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestLeaderRetry(test *testing.T) {
+	delay := leaderRetryDelay
+	leaderRetryDelay = time.Millisecond
+	defer func() { leaderRetryDelay = delay }()
+
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	applyLeaderRetry(client.Client, 2)
+
+	kv.errs = []error{rpctypes.ErrNoLeader, rpctypes.ErrGRPCNotLeader}
+	if _, err := client.Put(ctx, "/app/name", "passbase"); err != nil {
+		test.Fatalf("expected the write to succeed once the leader was elected, got %v", err)
+	}
+	assertKeyValue(test, kv, "/app/name", "passbase")
+
+	kv.errs = []error{rpctypes.ErrNoLeader}
+	response, err := client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value("/app/name"), "=", "passbase")).
+		Then(clientv3.OpPut("/app/name", "etcd")).
+		Commit()
+	if err != nil || !response.Succeeded {
+		test.Fatalf("expected the transaction to succeed once the leader was elected, got %v, %v", response, err)
+	}
+	assertKeyValue(test, kv, "/app/name", "etcd")
+
+	kv.errs = []error{rpctypes.ErrNoLeader, rpctypes.ErrNoLeader, rpctypes.ErrNoLeader}
+	_, err = client.Put(ctx, "/app/name", "other")
+	if diags := classifyEtcdError(err); !diags.HasError() || diags[0].Summary != "etcd leader election in progress" {
+		test.Fatalf("expected the leader election to be reported after the last retry, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/name", "etcd")
+
+	kv.errs = []error{rpctypes.ErrPermissionDenied}
+	if _, err := client.Put(ctx, "/app/name", "other"); err != rpctypes.ErrPermissionDenied {
+		test.Fatalf("expected other errors not to be retried, got %v", err)
+	}
+}
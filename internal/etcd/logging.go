@@ -0,0 +1,98 @@
+package etcd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// operationIDMetadataKey is the gRPC metadata key the operation ID is sent
+// under when propagate_operation_id is enabled, so it shows up next to the
+// request in etcd audit logs.
+const operationIDMetadataKey = "x-terraform-etcd-operation-id"
+
+// logSubsystem tags the log lines of the provider's own operations, to tell
+// them apart from those of the SDK and the etcd client.
+const logSubsystem = "etcd"
+
+type operationIDKey struct{}
+
+type clusterLogKey struct{}
+
+// startOperation tags ctx with a correlation ID shared by every log line and,
+// optionally, every etcd request of a single resource operation. A context
+// that already carries an ID keeps it, so nested calls such as a read after
+// an update are logged as part of the same operation.
+func (c *apiClient) startOperation(ctx context.Context) context.Context {
+	if operationID(ctx) != "" {
+		return ctx
+	}
+
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	ctx = context.WithValue(ctx, operationIDKey{}, id)
+	ctx = context.WithValue(ctx, clusterLogKey{}, fmt.Sprintf("endpoints=%q namespace=%q", strings.Join(c.endpoints, ","), c.namespace))
+	if c.propagateOperationID {
+		ctx = metadata.AppendToOutgoingContext(ctx, operationIDMetadataKey, id)
+	}
+	return ctx
+}
+
+// operationID returns the correlation ID of the operation running in ctx.
+func operationID(ctx context.Context) string {
+	id, _ := ctx.Value(operationIDKey{}).(string)
+	return id
+}
+
+// logf writes a log line at level, tagged with the operation ID of ctx and
+// the cluster it runs against.
+func logf(ctx context.Context, level string, format string, args ...interface{}) {
+	cluster, _ := ctx.Value(clusterLogKey{}).(string)
+	if cluster != "" {
+		cluster = " [" + cluster + "]"
+	}
+	log.Printf("[%s] [%s] [operation_id=%s]%s %s", level, logSubsystem, operationID(ctx), cluster, fmt.Sprintf(format, args...))
+}
+
+// logFields writes msg at level followed by fields as sorted key=value
+// pairs, so log lines of an operation can be filtered and parsed.
+func logFields(ctx context.Context, level string, msg string, fields map[string]interface{}) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		switch value := fields[name].(type) {
+		case string:
+			pairs[i] = fmt.Sprintf("%s=%q", name, value)
+		default:
+			pairs[i] = fmt.Sprintf("%s=%v", name, value)
+		}
+	}
+	logf(ctx, level, "%s: %s", msg, strings.Join(pairs, " "))
+}
+
+// logKeyOperation logs an operation on key that completed at revision,
+// timed from start.
+func logKeyOperation(ctx context.Context, level string, operation string, key string, revision int64, start time.Time, fields map[string]interface{}) {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["operation"] = operation
+	fields["key"] = key
+	fields["revision"] = revision
+	fields["duration"] = time.Since(start)
+	logFields(ctx, level, "etcd operation completed", fields)
+}
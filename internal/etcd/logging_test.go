@@ -0,0 +1,185 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// captureLogs returns the log lines written while fn runs.
+func captureLogs(fn func()) []string {
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(previous)
+	fn()
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestOperationIDSharedAcrossLogLines(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/name", "passbase")
+
+	state := &terraform.InstanceState{
+		ID:         "/app/name",
+		Attributes: map[string]string{"id": "/app/name", "key": "/app/name", "value": "passbase"},
+	}
+	lines := captureLogs(func() {
+		applyTestResource(test, KvResource(), state, map[string]interface{}{
+			"key":   "/app/name",
+			"value": "awesome",
+		}, newFakeClient(kv))
+	})
+
+	pattern := regexp.MustCompile(`\[operation_id=([0-9a-f]+)\]`)
+	ids := map[string]bool{}
+	for _, line := range lines {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		ids[match[1]] = true
+	}
+	if len(lines) < 2 || len(ids) != 1 {
+		test.Fatalf("expected every line of the update to share one operation ID, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestOperationIDTagsAuthResources(test *testing.T) {
+	client := newFakeAuthClient(newFakeAuth())
+	// etcd_role_permissions needs the role created first.
+	for _, tc := range []struct {
+		name     string
+		resource *schema.Resource
+		config   map[string]interface{}
+	}{
+		{"etcd_role", RoleResource(), map[string]interface{}{"name": "app"}},
+		{"etcd_user", UserResource(), map[string]interface{}{"username": "app", "password": "passbase1234"}},
+		{"etcd_role_permissions", RolePermissionsResource(), map[string]interface{}{
+			"role_name":  "app",
+			"permission": []interface{}{map[string]interface{}{"key": "/app/", "range_end": "/app0", "permission": "READ"}},
+		}},
+	} {
+		lines := captureLogs(func() {
+			if _, diags := applyTestResource(test, tc.resource, nil, tc.config, client); diags.HasError() {
+				test.Fatalf("%s: err: %v", tc.name, diags)
+			}
+		})
+		if !strings.Contains(strings.Join(lines, "\n"), "[operation_id=") {
+			test.Fatalf("%s: expected the create to be logged with an operation ID, got:\n%s", tc.name, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestOperationIDPropagatedAsMetadata(test *testing.T) {
+	client := &apiClient{propagateOperationID: true}
+
+	ctx := client.startOperation(context.Background())
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok || len(md.Get(operationIDMetadataKey)) != 1 || md.Get(operationIDMetadataKey)[0] != operationID(ctx) {
+		test.Fatalf("expected the operation ID in the outgoing metadata, got %v", md)
+	}
+
+	if client.startOperation(ctx) != ctx {
+		test.Fatalf("expected a nested operation to keep its ID")
+	}
+}
+
+func TestOperationIDSentWithRequests(test *testing.T) {
+	received := make(chan metadata.MD, 1)
+	endpoint := serveKV(test, grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		received <- md
+		return handler(ctx, req)
+	}))
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":              []interface{}{endpoint},
+		"check_health":           false,
+		"propagate_operation_id": true,
+	})
+	meta, diags := configure(context.Background(), d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	ctx := client.startOperation(context.Background())
+	if _, err := client.Put(ctx, "/app/name", "passbase"); err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	md := <-received
+	if ids := md.Get(operationIDMetadataKey); len(ids) != 1 || ids[0] != operationID(ctx) {
+		test.Fatalf("expected the operation ID in the request metadata, got %v", md)
+	}
+}
+
+func TestKvResourceCreateLogsOperation(test *testing.T) {
+	kv := newFakeKV()
+	lines := captureLogs(func() {
+		applyTestResource(test, KvResource(), nil, map[string]interface{}{
+			"key":   "/app/name",
+			"value": "passbase",
+		}, newFakeClient(kv))
+	})
+
+	pattern := regexp.MustCompile(`^.*\[DEBUG\] \[etcd\] \[operation_id=[0-9a-f]+\] \[endpoints="" namespace=""\] etcd operation completed: duration=\S+ key="/app/name" operation="create" revision=(\d+) value="passbase"$`)
+	for _, line := range lines {
+		if match := pattern.FindStringSubmatch(line); match != nil && match[1] != "0" {
+			return
+		}
+	}
+	test.Fatalf("expected a debug line for the create, got:\n%s", strings.Join(lines, "\n"))
+}
+
+func TestKvResourceSensitiveValueRedacted(test *testing.T) {
+	kv := newFakeKV()
+	lines := captureLogs(func() {
+		applyTestResource(test, KvResource(), nil, map[string]interface{}{
+			"key":       "/app/password",
+			"value":     "hunter2",
+			"sensitive": true,
+		}, newFakeClient(kv))
+	})
+
+	output := strings.Join(lines, "\n")
+	if strings.Contains(output, "hunter2") || !strings.Contains(output, `value="(sensitive)"`) {
+		test.Fatalf("expected the value to be redacted, got:\n%s", output)
+	}
+}
+
+func TestLogLinesCarryClusterIdentity(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.endpoints = []string{"10.0.0.1:2379", "10.0.0.2:2379"}
+	client.namespace = "/tenant/"
+
+	lines := captureLogs(func() {
+		applyTestResource(test, KvResource(), nil, map[string]interface{}{
+			"key":   "/app/name",
+			"value": "passbase",
+		}, client)
+	})
+
+	tagged := 0
+	for _, line := range lines {
+		if !strings.Contains(line, "[operation_id=") {
+			continue
+		}
+		if !strings.Contains(line, "["+logSubsystem+"]") || !strings.Contains(line, `[endpoints="10.0.0.1:2379,10.0.0.2:2379" namespace="/tenant/"]`) {
+			test.Fatalf("expected the subsystem and cluster identity on every line, got %q", line)
+		}
+		tagged++
+	}
+	if tagged == 0 {
+		test.Fatalf("expected log lines for the create, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
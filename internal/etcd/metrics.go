@@ -0,0 +1,167 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// metricsBuckets are the upper bounds, in seconds, of the operation duration
+// histogram.
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// operationMetrics records etcd RPCs issued by the provider and exposes them
+// in the Prometheus text format.
+type operationMetrics struct {
+	mu         sync.Mutex
+	operations map[string]uint64
+	errors     map[string]map[string]uint64
+	retries    map[string]uint64
+	durations  map[string]*histogram
+}
+
+func newOperationMetrics() *operationMetrics {
+	return &operationMetrics{
+		operations: map[string]uint64{},
+		errors:     map[string]map[string]uint64{},
+		retries:    map[string]uint64{},
+		durations:  map[string]*histogram{},
+	}
+}
+
+// unaryInterceptor runs inside the etcd client's retry interceptor, so it
+// observes every attempt. Attempts failing with Unavailable are the ones the
+// client retries and are counted as such.
+func (m *operationMetrics) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	m.observe(method, time.Since(start), err)
+	return err
+}
+
+func (m *operationMetrics) observe(method string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.operations[method]++
+
+	h, ok := m.durations[method]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(metricsBuckets))}
+		m.durations[method] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range metricsBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+
+	if err == nil {
+		return
+	}
+	code := status.Code(err)
+	if m.errors[method] == nil {
+		m.errors[method] = map[string]uint64{}
+	}
+	m.errors[method][code.String()]++
+	if code == codes.Unavailable {
+		m.retries[method]++
+	}
+}
+
+func sortedKeys(values map[string]uint64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *operationMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP etcd_provider_operations_total Number of etcd RPC attempts by method.")
+	fmt.Fprintln(w, "# TYPE etcd_provider_operations_total counter")
+	for _, method := range sortedKeys(m.operations) {
+		fmt.Fprintf(w, "etcd_provider_operations_total{method=%q} %d\n", method, m.operations[method])
+	}
+
+	fmt.Fprintln(w, "# HELP etcd_provider_operation_errors_total Number of failed etcd RPC attempts by method and gRPC code.")
+	fmt.Fprintln(w, "# TYPE etcd_provider_operation_errors_total counter")
+	methods := make([]string, 0, len(m.errors))
+	for method := range m.errors {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		for _, code := range sortedKeys(m.errors[method]) {
+			fmt.Fprintf(w, "etcd_provider_operation_errors_total{method=%q,code=%q} %d\n", method, code, m.errors[method][code])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP etcd_provider_operation_retries_total Number of etcd RPC attempts that failed with a retryable error.")
+	fmt.Fprintln(w, "# TYPE etcd_provider_operation_retries_total counter")
+	for _, method := range sortedKeys(m.retries) {
+		fmt.Fprintf(w, "etcd_provider_operation_retries_total{method=%q} %d\n", method, m.retries[method])
+	}
+
+	fmt.Fprintln(w, "# HELP etcd_provider_operation_duration_seconds Duration of etcd RPC attempts by method.")
+	fmt.Fprintln(w, "# TYPE etcd_provider_operation_duration_seconds histogram")
+	for _, method := range sortedKeys(m.operations) {
+		h := m.durations[method]
+		for i, bound := range metricsBuckets {
+			fmt.Fprintf(w, "etcd_provider_operation_duration_seconds_bucket{method=%q,le=\"%g\"} %d\n", method, bound, h.counts[i])
+		}
+		fmt.Fprintf(w, "etcd_provider_operation_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", method, h.count)
+		fmt.Fprintf(w, "etcd_provider_operation_duration_seconds_sum{method=%q} %g\n", method, h.sum)
+		fmt.Fprintf(w, "etcd_provider_operation_duration_seconds_count{method=%q} %d\n", method, h.count)
+	}
+}
+
+// serveMetrics exposes metrics on addr under /metrics until ctx is done.
+func serveMetrics(ctx context.Context, addr string, metrics *operationMetrics) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen for metrics on %s: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[ERROR] metrics server stopped: %v", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	return listener.Addr(), nil
+}
@@ -0,0 +1,102 @@
+package etcd
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func scrapeMetrics(test *testing.T, url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	return string(body)
+}
+
+func TestMetricsEndpointCountsOperations(test *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newOperationMetrics()
+	addr, err := serveMetrics(ctx, "127.0.0.1:0", metrics)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	url := "http://" + addr.String() + "/metrics"
+
+	const counter = `etcd_provider_operations_total{method="/etcdserverpb.KV/Range"}`
+	if body := scrapeMetrics(test, url); strings.Contains(body, counter) {
+		test.Fatalf("expected no operations before any RPC, got:\n%s", body)
+	}
+
+	ok := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+	unavailable := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	metrics.unaryInterceptor(ctx, "/etcdserverpb.KV/Range", nil, nil, nil, unavailable)
+	metrics.unaryInterceptor(ctx, "/etcdserverpb.KV/Range", nil, nil, nil, ok)
+
+	body := scrapeMetrics(test, url)
+	for _, expected := range []string{
+		counter + " 2",
+		`etcd_provider_operation_errors_total{method="/etcdserverpb.KV/Range",code="Unavailable"} 1`,
+		`etcd_provider_operation_retries_total{method="/etcdserverpb.KV/Range"} 1`,
+		`etcd_provider_operation_duration_seconds_count{method="/etcdserverpb.KV/Range"} 2`,
+	} {
+		if !strings.Contains(body, expected) {
+			test.Fatalf("expected %q in:\n%s", expected, body)
+		}
+	}
+}
+
+func TestConfigureMetricsRetry(test *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	listen := listener.Addr().String()
+	listener.Close()
+
+	// A failed configure does not keep the metrics address bound.
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":      []interface{}{"127.0.0.1:1"},
+		"dial_timeout":   "200ms",
+		"metrics_listen": listen,
+	})
+	if _, diags := configure(context.Background(), d); !diags.HasError() {
+		test.Fatalf("expected the unreachable endpoint to fail configure")
+	}
+	if _, err := http.Get("http://" + listen + "/metrics"); err == nil {
+		test.Fatalf("expected no metrics to be served after a failed configure")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d = schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":      []interface{}{serveKV(test)},
+		"check_health":   false,
+		"metrics_listen": listen,
+	})
+	meta, diags := configure(ctx, d)
+	if diags.HasError() {
+		test.Fatalf("expected configure to be retried on the same address, got %v", diags)
+	}
+	defer meta.(*apiClient).Close()
+	scrapeMetrics(test, "http://"+listen+"/metrics")
+}
@@ -0,0 +1,65 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	etcd "go.etcd.io/etcd/client/v3"
+)
+
+func validateVersion(v interface{}, k string) ([]string, []error) {
+	if v.(string) == "" {
+		return nil, nil
+	}
+	if _, err := semver.NewVersion(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q: %v", k, err)}
+	}
+	return nil, nil
+}
+
+// checkMinVersion requests the status of every endpoint and reports the ones
+// running an etcd older than minVersion, so a rolling upgrade left halfway
+// is caught too. Endpoints that do not answer are left to the health check,
+// but at least one has to.
+func checkMinVersion(ctx context.Context, maintenance etcd.Maintenance, endpoints []string, minVersion string, timeout time.Duration) diag.Diagnostics {
+	required, err := semver.NewVersion(minVersion)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	answered := 0
+	older := []string{}
+	for _, endpoint := range endpoints {
+		statusCtx, cancel := context.WithTimeout(ctx, timeout)
+		status, err := maintenance.Status(statusCtx, endpoint)
+		cancel()
+		if err != nil {
+			continue
+		}
+		answered++
+
+		version, err := semver.NewVersion(status.Version)
+		if err != nil {
+			return diag.Errorf("unable to parse the etcd version %q of endpoint %s: %v", status.Version, endpoint, err)
+		}
+		if version.LessThan(*required) {
+			older = append(older, fmt.Sprintf("%s: %s", endpoint, version))
+		}
+	}
+
+	if answered == 0 {
+		return diag.Errorf("unable to check the etcd version, no endpoint answered a status request")
+	}
+	if len(older) == 0 {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "etcd version too old",
+		Detail:   fmt.Sprintf("The provider requires etcd %s or newer, set by min_version, but the following endpoints run an older version:\n\n%s", required, strings.Join(older, "\n")),
+	}}
+}
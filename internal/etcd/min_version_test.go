@@ -0,0 +1,49 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckMinVersion(test *testing.T) {
+	maintenance := newFakeMaintenance()
+	maintenance.addEndpoint("etcd-0:2379", 1024)
+	maintenance.addEndpoint("etcd-1:2379", 1024)
+	maintenance.status["etcd-1:2379"].Version = "3.4.16"
+	endpoints := []string{"etcd-0:2379", "etcd-1:2379", "etcd-2:2379"}
+
+	for _, tc := range []struct {
+		minVersion string
+		expected   string
+	}{
+		{"3.4.0", ""},
+		{"3.4.16", ""},
+		{"3.5.0", "etcd-1:2379: 3.4.16"},
+		{"3.6.0", "etcd-0:2379: 3.5.0\netcd-1:2379: 3.4.16"},
+	} {
+		diags := checkMinVersion(context.Background(), maintenance, endpoints, tc.minVersion, time.Second)
+		if tc.expected == "" {
+			if diags.HasError() {
+				test.Fatalf("%s: err: %v", tc.minVersion, diags)
+			}
+			continue
+		}
+		if !diags.HasError() || diags[0].Summary != "etcd version too old" || !strings.HasSuffix(diags[0].Detail, tc.expected) {
+			test.Fatalf("%s: expected %q to be reported, got %v", tc.minVersion, tc.expected, diags)
+		}
+	}
+
+	if diags := checkMinVersion(context.Background(), maintenance, []string{"etcd-2:2379"}, "3.4.0", time.Second); !diags.HasError() {
+		test.Fatalf("expected an error without any endpoint answering")
+	}
+}
+
+func TestValidateVersion(test *testing.T) {
+	for version, valid := range map[string]bool{"": true, "3.5.0": true, "3.6.0-alpha.0": true, "3.5": false, "latest": false} {
+		if _, errs := validateVersion(version, "min_version"); (len(errs) == 0) != valid {
+			test.Fatalf("%q: expected valid %v, got %v", version, valid, errs)
+		}
+	}
+}
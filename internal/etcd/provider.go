@@ -2,12 +2,20 @@ package etcd
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	etcd "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/namespace"
+	"google.golang.org/grpc"
 )
 
 func init() {
@@ -30,39 +38,289 @@ func New() *schema.Provider {
 	p := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"endpoints": &schema.Schema{
-				Type:     schema.TypeList,
-				Required: true,
+				Type:         schema.TypeList,
+				Optional:     true,
+				MinItems:     1,
+				ExactlyOneOf: []string{"endpoints", "discovery_srv"},
 				//DefaultFunc: schema.EnvDefaultFunc("ENDPOINTS", []string{"localhost:2379"}),
 				Elem: &schema.Schema{Type: schema.TypeString},
 			},
-			
+			"discovery_srv": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"endpoints", "discovery_srv"},
+				Description:  "Domain whose `_etcd-client-ssl._tcp` or `_etcd-client._tcp` SRV records list the endpoints, resolved when the provider is configured, instead of `endpoints`.",
+			},
+			"balancer": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "round_robin",
+				ValidateFunc: validation.StringInSlice([]string{"round_robin", "pick_first"}, false),
+				Description:  "How requests are spread across the endpoints, `round_robin` or `pick_first`.",
+			},
+
 			"username": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("ETCD_USERNAME", ""),
+				Description: "User to authenticate as. Can be set with `ETCD_USERNAME`, or with `ETCDCTL_USER` in `user:password` form.",
 			},
 			"password": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
+				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("ETCD_PASSWORD", ""),
-				
+				Description: "Password of `username`. Can be set with `ETCD_PASSWORD`.",
+			},
+			"cert_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ETCDCTL_CERT", ""),
+				Description: "Path to the client certificate. Can be set with `ETCDCTL_CERT`.",
+			},
+			"key_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ETCDCTL_KEY", ""),
+				Description: "Path to the client certificate key. Can be set with `ETCDCTL_KEY`.",
+			},
+			"ca_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ETCDCTL_CACERT", ""),
+				Description: "Path to the CA bundle used to verify the servers. Can be set with `ETCDCTL_CACERT`.",
+			},
+			"insecure_skip_verify": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Connect over TLS without verifying the server certificates.",
+			},
+			"tls_server_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "Name the server certificates are verified against instead of the host of the endpoints, such as when connecting through a load balancer.",
+			},
+			"metrics_listen": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "Address (host:port) to expose Prometheus metrics about etcd operations on. Disabled when empty.",
+			},
+			"propagate_operation_id": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Send the correlation ID of each resource operation to etcd as gRPC metadata.",
+			},
+			"dial_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "5s",
+				ValidateFunc: validateNonNegativeDuration,
+				Description:  "How long to wait for the connection to the cluster, as a duration such as `5s`.",
+			},
+			"block": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Wait up to `dial_timeout` for the connection to the cluster when the provider is configured, failing right away when no endpoint accepts it, instead of connecting on the first request.",
+			},
+			"request_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateNonNegativeDuration,
+				Description:  "How long a resource or data source operation may take, as a duration such as `10s`.",
+			},
+			"auto_sync_interval": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "0s",
+				ValidateFunc: validateNonNegativeDuration,
+				Description:  "How often to refresh the endpoints from the cluster member list, as a duration such as `5m`. Disabled when zero.",
+			},
+			"keepalive_time": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "0s",
+				ValidateFunc: validateNonNegativeDuration,
+				Description:  "How long the connection may be idle before the client pings the server, as a duration such as `30s`. Disabled when zero.",
+			},
+			"keepalive_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateNonNegativeDuration,
+				Description:  "How long to wait for a ping response before closing the connection. Must be less than `keepalive_time`.",
+			},
+			"permit_without_stream": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Send keepalive pings even when no RPC is in flight.",
+			},
+			"namespace": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "Prefix prepended to every key, lease and watch, so resources only see keys below it.",
+			},
+			"verify_cluster_id": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Record the ID of the cluster on the first request and fail every later operation answered by a different cluster. Writes are checked with a status request before they are sent.",
+			},
+			"key_validation": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "none",
+				ValidateFunc: validation.StringInSlice(keyValidationRules, false),
+				Description:  "Rule keys of `etcd_key_value` must follow, checked at plan time: `none`, `no_trailing_slash` or `printable_ascii`.",
+			},
+			"max_value_bytes": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultMaxValueBytes,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Largest value `etcd_key_value` may write, checked at plan time. Should match the `--max-request-bytes` of the cluster, `0` disables the check.",
+			},
+			"max_call_send_msg_size": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Largest request in bytes the client sends, `0` keeps the client default of 2 MiB. Must be larger than `max_value_bytes` and should not exceed the `--max-request-bytes` of the cluster.",
+			},
+			"max_call_recv_msg_size": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Largest response in bytes the client accepts, `0` keeps the client default of no limit. Must not be smaller than `max_call_send_msg_size`.",
+			},
+			"max_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "How many times a key-value request rejected because the cluster has no leader is retried, waiting for the leader election between attempts. `0` disables the retries.",
+			},
+			"min_version": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateVersion,
+				Description:  "Oldest etcd version the cluster may run, such as `3.5.0`, checked on every endpoint when the provider is configured. Empty disables the check.",
+			},
+			"wait_for_quorum": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Hold back the first write until the cluster has a leader and a majority of the endpoints are healthy, for clusters recovering from a restart.",
+			},
+			"wait_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "1m",
+				ValidateFunc: validateNonNegativeDuration,
+				Description:  "How long `wait_for_quorum` waits for quorum before failing the write, as a duration such as `1m`.",
+			},
+			"on_external_delete": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "recreate",
+				ValidateFunc: validation.StringInSlice([]string{"recreate", "error"}, false),
+				Description:  "What refreshing an `etcd_key_value` whose key was deleted outside of Terraform does: `recreate` removes it from state so the next apply creates it again, `error` fails the refresh.",
+			},
+			"parallelism": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      4,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "How many transactions `etcd_key_values` commits at once.",
+			},
+			"proxy_url": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateProxyURL,
+				Description:  "Proxy to connect to the endpoints through, such as `socks5://proxy:1080` or `http://proxy:3128`.",
+			},
+			"debug_grpc": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Log the method, duration and status code of every etcd RPC at `TRACE`, for debugging.",
+			},
+			"debug_grpc_payloads": &schema.Schema{
+				Type:         schema.TypeBool,
+				Optional:     true,
+				Default:      false,
+				RequiredWith: []string{"debug_grpc"},
+				Description:  "Also log the request and response messages of the RPCs logged by `debug_grpc`, including the keys and values read and written.",
+			},
+			"check_health": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Check that every endpoint answers a status request when the provider is configured.",
 			},
-		
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"etcd_key_value":             KvResource(),
-			"etcd_role":                  RoleResource(),
-			"etcd_user":                  UserResource(),
-			"etcd_grant_user_role":       RoleGrantResource(),
-			"etcd_grant_role_permission": RolePermissionResource(),
-			"etcd_auth": AuthResource(),
+			"etcd_key_value":             applyRequestTimeout(KvResource()),
+			"etcd_role":                  applyRequestTimeout(RoleResource()),
+			"etcd_user":                  applyRequestTimeout(UserResource()),
+			"etcd_grant_user_role":       applyRequestTimeout(RoleGrantResource()),
+			"etcd_grant_role_permission": applyRequestTimeout(RolePermissionResource()),
+			"etcd_auth":                  applyRequestTimeout(AuthResource()),
+			"etcd_role_permissions":      applyRequestTimeout(RolePermissionsResource()),
+			"etcd_key_alias":             applyRequestTimeout(KeyAliasResource()),
+			"etcd_move_key":              applyRequestTimeout(MoveKeyResource()),
+			"etcd_keepalive_manager":     applyRequestTimeout(KeepAliveManagerResource()),
+			"etcd_lease":                 applyRequestTimeout(LeaseResource()),
+			"etcd_prefix":                applyRequestTimeout(PrefixResource()),
+			"etcd_user_role_binding":     applyRequestTimeout(UserRoleBindingResource()),
+			"etcd_auth_enable":           applyRequestTimeout(AuthEnableResource()),
+			"etcd_txn":                   applyRequestTimeout(TxnResource()),
+			"etcd_lock":                  applyRequestTimeout(LockResource()),
+			"etcd_compaction":            applyRequestTimeout(CompactionResource()),
+			"etcd_alarm_disarm":          applyRequestTimeout(AlarmDisarmResource()),
+			"etcd_defragment":            applyRequestTimeout(DefragmentResource()),
+			"etcd_member":                applyRequestTimeout(MemberResource()),
+			"etcd_member_promote":        applyRequestTimeout(MemberPromoteResource()),
+			"etcd_key_values":            applyRequestTimeout(KvBatchResource()),
+			"etcd_key_ensure":            applyRequestTimeout(KeyEnsureResource()),
+			"etcd_prefix_delete":         applyRequestTimeout(PrefixDeleteResource()),
+			// Waiting for a key is bounded by its own timeout instead.
+			"etcd_key_wait": KeyWaitResource(),
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"etcd_cluster":   ClusterDataSource(),
-			"etcd_users":     UsersDataSource(),
-			"etcd_key_value": KeyValueDataSource(),
+			"etcd_cluster":           applyRequestTimeout(ClusterDataSource()),
+			"etcd_users":             applyRequestTimeout(UsersDataSource()),
+			"etcd_key_value":         applyRequestTimeout(KeyValueDataSource()),
+			"etcd_cluster_usage":     applyRequestTimeout(ClusterUsageDataSource()),
+			"etcd_cluster_members":   applyRequestTimeout(MembersDataSource()),
+			"etcd_auth_status":       applyRequestTimeout(AuthStatusDataSource()),
+			"etcd_prefix":            applyRequestTimeout(PrefixDataSource()),
+			"etcd_prefix_count":      applyRequestTimeout(PrefixCountDataSource()),
+			"etcd_cluster_status":    applyRequestTimeout(StatusDataSource()),
+			"etcd_alarm":             applyRequestTimeout(AlarmsDataSource()),
+			"etcd_key_value_default": applyRequestTimeout(KvDefaultDataSource()),
+			"etcd_leases":            applyRequestTimeout(LeasesDataSource()),
+			"etcd_revision":          applyRequestTimeout(RevisionDataSource()),
+			"etcd_schema_validation": applyRequestTimeout(KvSchemaValidateDataSource()),
+			"etcd_endpoint_health":   applyRequestTimeout(EndpointHealthDataSource()),
+			// Reading tombstones blocks for the configured window instead.
+			"etcd_prefix_tombstones": PrefixTombstonesDataSource(),
+			// Watching blocks until the events arrive or its own timeout.
+			"etcd_watch": KvWatchDataSource(),
+			// Downloading a snapshot takes as long as the database is large.
+			"etcd_snapshot": SnapshotDataSource(),
 		},
 	}
 
@@ -74,12 +332,104 @@ func New() *schema.Provider {
 
 type apiClient struct {
 	*etcd.Client
+
+	// endpoints are the configured cluster endpoints.
+	endpoints []string
+
+	// namespace is the prefix of every key, lease and watch of the client.
+	namespace string
+
+	// propagateOperationID sends operation IDs to etcd as gRPC metadata.
+	propagateOperationID bool
+
+	// keepAlives keeps leases alive for the lifetime of the provider.
+	keepAlives *keepAliveManager
+
+	// requestTimeout bounds every resource operation.
+	requestTimeout time.Duration
+
+	// locks are the etcd_lock locks held by this process, by lock key.
+	locks sync.Map
+
+	// clusterGuard holds the cluster ID when verify_cluster_id is set.
+	clusterGuard *clusterIDGuard
+
+	// keyValidation is the key_validation rule keys are checked against.
+	keyValidation string
+
+	// maxValueBytes is the largest value keys are allowed to hold, 0 when
+	// unbounded.
+	maxValueBytes int
+
+	// parallelism is how many transactions batch resources commit at once.
+	parallelism int
+
+	// onExternalDelete is what reading a key deleted outside of Terraform
+	// does, recreate or error.
+	onExternalDelete string
+
+	// scoped holds the clients of resources that override the endpoints.
+	scoped *scopedClients
+
+	// release gives up this provider instance's hold on Client.
+	release func()
+
+	// operations is held for reading by every operation in flight, and for
+	// writing while the client is closed.
+	operations sync.RWMutex
+	closed     bool
+	closeOnce  sync.Once
+}
+
+// beginOperation registers an operation in flight, so closing the client
+// waits for it. It returns false once the client is closed.
+func (c *apiClient) beginOperation() bool {
+	c.operations.RLock()
+	if c.closed {
+		c.operations.RUnlock()
+		return false
+	}
+	return true
+}
+
+func (c *apiClient) endOperation() {
+	c.operations.RUnlock()
+}
+
+// Close waits for the operations in flight, stops the lease keep-alives and
+// releases the etcd clients. The provider's client is only closed once no
+// other provider instance shares it. Closing again is a no-op.
+func (c *apiClient) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.operations.Lock()
+		defer c.operations.Unlock()
+		c.closed = true
+
+		if c.keepAlives != nil {
+			c.keepAlives.close()
+		}
+		if c.scoped != nil {
+			c.scoped.mu.Lock()
+			for _, scoped := range c.scoped.clients {
+				scoped.Close()
+			}
+			c.scoped.clients = nil
+			c.scoped.mu.Unlock()
+		}
+		if c.release == nil {
+			err = c.Client.Close()
+			return
+		}
+		c.release()
+	})
+	return err
 }
 
 func configure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	var (
-		err   error
-		cli   *etcd.Client
+		err error
+		cli *etcd.Client
 	)
 	urls := []string{}
 
@@ -94,20 +444,309 @@ func configure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.D
 	} else {
 		urls = append(urls, endpoints...)
 	}
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
-	
-	cli, err = etcd.New(etcd.Config{
-		Endpoints:   urls,
-		DialTimeout: 5 * time.Second,
-		RejectOldCluster: false,
-		Username : username,
-		Password: password,
-	})
+	if domain := d.Get("discovery_srv").(string); domain != "" {
+		discovered, diags := discoverEndpoints(ctx, discoveryResolver, domain)
+		if diags.HasError() {
+			return nil, diags
+		}
+		urls = discovered
+	}
+	if len(urls) == 0 {
+		return nil, diag.Errorf("at least one endpoint must be configured")
+	}
+	username, password := credentials(d)
+
+	dialTimeout, err := time.ParseDuration(d.Get("dial_timeout").(string))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	requestTimeout, err := time.ParseDuration(d.Get("request_timeout").(string))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	autoSyncInterval, err := time.ParseDuration(d.Get("auto_sync_interval").(string))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	keepAliveTime, keepAliveTimeout, err := keepAliveSettings(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	waitTimeout, err := time.ParseDuration(d.Get("wait_timeout").(string))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	if diags := checkMessageSizes(d); diags.HasError() {
+		return nil, diags
+	}
+
+	config := etcd.Config{
+		Endpoints:            urls,
+		DialTimeout:          dialTimeout,
+		AutoSyncInterval:     autoSyncInterval,
+		DialKeepAliveTime:    keepAliveTime,
+		DialKeepAliveTimeout: keepAliveTimeout,
+		PermitWithoutStream:  d.Get("permit_without_stream").(bool),
+		MaxCallSendMsgSize:   d.Get("max_call_send_msg_size").(int),
+		MaxCallRecvMsgSize:   d.Get("max_call_recv_msg_size").(int),
+		RejectOldCluster:     false,
+		Username:             username,
+		Password:             password,
+		Context:              clientContext(ctx),
+	}
+
+	applyBalancer(&config, d.Get("balancer").(string))
+	block := d.Get("block").(bool)
+	if block {
+		config.DialOptions = append(config.DialOptions, grpc.WithBlock())
+	}
+
+	config.TLS, err = buildTLSConfig(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	config.DialOptions = append(config.DialOptions, grpc.WithChainUnaryInterceptor(servedByInterceptor))
+
+	var metrics *operationMetrics
+	if listen := d.Get("metrics_listen").(string); listen != "" {
+		metrics = newOperationMetrics()
+		config.DialOptions = append(config.DialOptions, grpc.WithChainUnaryInterceptor(metrics.unaryInterceptor))
+	}
+
+	if proxy := d.Get("proxy_url").(string); proxy != "" {
+		proxyURL, err := parseProxyURL(proxy)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		dialer, err := proxyDialer(proxyURL)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		config.DialOptions = append(config.DialOptions, grpc.WithContextDialer(dialer))
+	}
+
+	if d.Get("debug_grpc").(bool) {
+		debug := &grpcDebugLogger{payloads: d.Get("debug_grpc_payloads").(bool)}
+		config.DialOptions = append(config.DialOptions, grpc.WithChainUnaryInterceptor(debug.unaryInterceptor))
+	}
+
+	var guard *clusterIDGuard
+	if d.Get("verify_cluster_id").(bool) {
+		guard = &clusterIDGuard{}
+	}
+
+	namespace := d.Get("namespace").(string)
+	maxRetries := d.Get("max_retries").(int)
+	waitForQuorum := d.Get("wait_for_quorum").(bool)
+	// Every client records the cluster ID of its own endpoints, so guard is
+	// given per dial rather than shared through config.
+	dial := func(config etcd.Config, guard *clusterIDGuard) (*etcd.Client, error) {
+		if guard != nil {
+			config.DialOptions = append(append([]grpc.DialOption{}, config.DialOptions...), grpc.WithChainUnaryInterceptor(guard.unaryInterceptor))
+		}
+		cli, err := etcd.New(config)
+		if err != nil {
+			return nil, err
+		}
+		applyNamespace(cli, namespace)
+		applyLeaderRetry(cli, maxRetries)
+		if waitForQuorum {
+			applyQuorumWait(cli, waitTimeout)
+		}
+		return cli, nil
+	}
 
+	// The interceptors of metrics_listen and verify_cluster_id belong to
+	// this provider instance, so such clients are not shared.
+	var release func()
+	if listen := d.Get("metrics_listen").(string); listen == "" && guard == nil {
+		// The shared client outlives the provider instance that dialed it
+		// and is closed by the last one to release it instead.
+		sharedConfig := config
+		sharedConfig.Context = context.Background()
+		cli, release, err = sharedClients.acquire(newClientCacheKey(d, config), func() (*etcd.Client, error) {
+			return dial(sharedConfig, nil)
+		})
+	} else if cli, err = dial(config, guard); err == nil {
+		release = func() { cli.Close() }
+	}
+
+	if err == rpctypes.ErrAuthFailed {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "etcd authentication failed",
+			Detail:   fmt.Sprintf("The cluster rejected the credentials of user %q, check the provider username and password.", username),
+		}}
+	}
+	if block && err == context.DeadlineExceeded {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to connect to etcd",
+			Detail:   fmt.Sprintf("No endpoint of %s accepted a connection within the dial_timeout of %s.", strings.Join(urls, ", "), dialTimeout),
+		}}
+	}
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
-	return &apiClient{cli}, nil 
-}
\ No newline at end of file
+	if d.Get("check_health").(bool) {
+		if diags := checkEndpointHealth(ctx, cli, urls, dialTimeout); diags.HasError() {
+			release()
+			return nil, diags
+		}
+	}
+	if minVersion := d.Get("min_version").(string); minVersion != "" {
+		if diags := checkMinVersion(ctx, cli, urls, minVersion, dialTimeout); diags.HasError() {
+			release()
+			return nil, diags
+		}
+	}
+	// The metrics endpoint is only served once the client is usable, so a
+	// failed configure does not leave its address bound for the retry.
+	if metrics != nil {
+		if _, err := serveMetrics(config.Context, d.Get("metrics_listen").(string), metrics); err != nil {
+			release()
+			return nil, diag.FromErr(err)
+		}
+	}
+	scoped := &scopedClients{
+		dial: func(ctx context.Context, endpoints []string, guard *clusterIDGuard) (*etcd.Client, error) {
+			scopedConfig := config
+			scopedConfig.Context = ctx
+			scopedConfig.Endpoints = endpoints
+			return dial(scopedConfig, guard)
+		},
+	}
+
+	client := &apiClient{
+		Client:               cli,
+		endpoints:            urls,
+		namespace:            namespace,
+		propagateOperationID: d.Get("propagate_operation_id").(bool),
+		keepAlives:           newKeepAliveManager(config.Context, cli),
+		requestTimeout:       requestTimeout,
+		clusterGuard:         guard,
+		keyValidation:        d.Get("key_validation").(string),
+		maxValueBytes:        d.Get("max_value_bytes").(int),
+		parallelism:          d.Get("parallelism").(int),
+		onExternalDelete:     d.Get("on_external_delete").(string),
+		scoped:               scoped,
+		release:              release,
+	}
+	releaseOnStop(ctx, func() { client.Close() })
+	return client, nil
+}
+
+// checkMessageSizes rejects message sizes the client could not use: a send
+// size leaving no room for a value of max_value_bytes, which the cluster
+// would otherwise only reject on apply, or a receive size smaller than it.
+func checkMessageSizes(d *schema.ResourceData) diag.Diagnostics {
+	send := d.Get("max_call_send_msg_size").(int)
+	recv := d.Get("max_call_recv_msg_size").(int)
+	maxValue := d.Get("max_value_bytes").(int)
+
+	if send > 0 && maxValue > 0 && send <= maxValue {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "max_call_send_msg_size is too small",
+			Detail:   fmt.Sprintf("A request of max_call_send_msg_size = %d bytes cannot carry a value of max_value_bytes = %d bytes and its key, raise max_call_send_msg_size or lower max_value_bytes.", send, maxValue),
+		}}
+	}
+	if send > 0 && recv > 0 && recv < send {
+		return diag.Errorf("max_call_recv_msg_size (%d) must not be smaller than max_call_send_msg_size (%d)", recv, send)
+	}
+	return nil
+}
+
+// checkEndpointHealth requests the status of every endpoint, waiting up to
+// timeout for each, and reports all the endpoints that did not answer at once.
+func checkEndpointHealth(ctx context.Context, maintenance etcd.Maintenance, endpoints []string, timeout time.Duration) diag.Diagnostics {
+	unreachable := []string{}
+	for _, endpoint := range endpoints {
+		statusCtx, cancel := context.WithTimeout(ctx, timeout)
+		_, err := maintenance.Status(statusCtx, endpoint)
+		cancel()
+		if err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%s: %v", endpoint, err))
+		}
+	}
+	if len(unreachable) == 0 {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "etcd endpoints unreachable",
+		Detail:   fmt.Sprintf("The following endpoints did not answer a status request, check the provider endpoints or set check_health = false if they come up later:\n\n%s", strings.Join(unreachable, "\n")),
+	}}
+}
+
+// applyBalancer configures how requests are spread across the endpoints. The
+// etcd resolver already asks gRPC for round_robin, other policies have to
+// replace the service config it provides. With a single endpoint every policy
+// sends all the requests to it.
+func applyBalancer(config *etcd.Config, balancer string) {
+	if balancer == "round_robin" {
+		return
+	}
+	config.DialOptions = append(config.DialOptions,
+		grpc.WithDisableServiceConfig(),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy": %q}`, balancer)),
+	)
+}
+
+// keepAliveSettings returns the gRPC keepalive time and timeout. A ping that
+// times out after the next one is due would never be noticed, so the timeout
+// has to be shorter than the time.
+func keepAliveSettings(d *schema.ResourceData) (time.Duration, time.Duration, error) {
+	keepAliveTime, err := time.ParseDuration(d.Get("keepalive_time").(string))
+	if err != nil {
+		return 0, 0, err
+	}
+	keepAliveTimeout, err := time.ParseDuration(d.Get("keepalive_timeout").(string))
+	if err != nil {
+		return 0, 0, err
+	}
+	if keepAliveTime > 0 && keepAliveTimeout >= keepAliveTime {
+		return 0, 0, fmt.Errorf("keepalive_timeout (%s) must be less than keepalive_time (%s)", keepAliveTimeout, keepAliveTime)
+	}
+	return keepAliveTime, keepAliveTimeout, nil
+}
+
+// applyNamespace scopes the KV, Lease and Watcher of client to ns. Keys are
+// prefixed on the way to etcd and stripped on the way back, so resources and
+// the IDs in state keep using unprefixed keys.
+func applyNamespace(client *etcd.Client, ns string) {
+	if ns == "" {
+		return
+	}
+	client.KV = namespace.NewKV(client.KV, ns)
+	client.Lease = namespace.NewLease(client.Lease, ns)
+	client.Watcher = namespace.NewWatcher(client.Watcher, ns)
+}
+
+// credentials returns the configured username and password, falling back to
+// ETCDCTL_USER, which etcdctl reads in "user:password" form.
+func credentials(d *schema.ResourceData) (string, string) {
+	username := d.Get("username").(string)
+	password := d.Get("password").(string)
+	if username != "" {
+		return username, password
+	}
+	parts := strings.SplitN(os.Getenv("ETCDCTL_USER"), ":", 2)
+	if password == "" && len(parts) == 2 {
+		password = parts[1]
+	}
+	return parts[0], password
+}
+
+// clientContext returns the context the etcd client lives in. The configure
+// context is request scoped, so the client is tied to the provider stop
+// context instead, which Terraform cancels when it stops the provider and
+// which in turn tears down the client's background goroutines.
+func clientContext(ctx context.Context) context.Context {
+	if stopCtx, ok := schema.StopContext(ctx); ok {
+		return stopCtx
+	}
+	return context.Background()
+}
@@ -1,7 +1,23 @@
 package etcd
 
 import (
+	"context"
+	"net"
+	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	etcd "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
 )
 
 func TestProvider(test *testing.T) {
@@ -10,3 +26,419 @@ func TestProvider(test *testing.T) {
 		test.Fatalf("err: %s", err)
 	}
 }
+
+func TestConfigureClientStopsWithProvider(test *testing.T) {
+	stopCtx, stop := context.WithCancel(context.Background())
+	ctx := context.WithValue(context.Background(), schema.StopContextKey, stopCtx)
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":    []interface{}{"127.0.0.1:0"},
+		"check_health": false,
+	})
+	meta, diags := configure(ctx, d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	select {
+	case <-client.Ctx().Done():
+		test.Fatalf("client context was cancelled before the provider stopped")
+	default:
+	}
+
+	stop()
+
+	select {
+	case <-client.Ctx().Done():
+	case <-time.After(5 * time.Second):
+		test.Fatalf("client context was not cancelled after the provider stopped")
+	}
+}
+
+func TestProviderStopClosesClients(test *testing.T) {
+	stopCtx, stop := context.WithCancel(context.Background())
+	ctx := context.WithValue(context.Background(), schema.StopContextKey, stopCtx)
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":    []interface{}{serveKV(test)},
+		"check_health": false,
+	})
+	meta, diags := configure(ctx, d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	scoped, err := client.forEndpoints(ctx, []string{serveKV(test)})
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	for _, c := range []*apiClient{client, scoped} {
+		if _, err := c.Put(ctx, "/app/name", "passbase"); err != nil {
+			test.Fatalf("err: %s", err)
+		}
+	}
+
+	// An operation in flight holds the client open until it returns.
+	running, finish := make(chan struct{}), make(chan struct{})
+	operation := withRequestTimeout(func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		close(running)
+		<-finish
+		_, err := meta.(*apiClient).Put(ctx, "/app/name", "awesome")
+		return diag.FromErr(err)
+	})
+	result := make(chan diag.Diagnostics)
+	go func() { result <- operation(context.Background(), nil, client) }()
+	<-running
+
+	stop()
+	time.Sleep(50 * time.Millisecond)
+	if state := client.ActiveConnection().GetState(); state == connectivity.Shutdown {
+		test.Fatalf("expected the client to stay open while an operation is in flight")
+	}
+	close(finish)
+	if diags := <-result; diags.HasError() {
+		test.Fatalf("expected the operation in flight to complete, got %v", diags)
+	}
+
+	for _, c := range []*apiClient{client, scoped} {
+		deadline := time.Now().Add(5 * time.Second)
+		for c.ActiveConnection().GetState() != connectivity.Shutdown {
+			if time.Now().After(deadline) {
+				test.Fatalf("expected the connection to be closed after the provider stopped, got %s", c.ActiveConnection().GetState())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if err := client.Close(); err != nil {
+		test.Fatalf("expected closing again to be a no-op, got %s", err)
+	}
+	if diags := operation(context.Background(), nil, client); !diags.HasError() || !strings.Contains(diags[0].Summary, "shutting down") {
+		test.Fatalf("expected operations to be refused once the client is closed, got %v", diags)
+	}
+}
+
+// authServer is an etcd Auth service that only accepts root:secret.
+type authServer struct {
+	pb.UnimplementedAuthServer
+}
+
+func (*authServer) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	if req.Name != "root" || req.Password != "secret" {
+		return nil, rpctypes.ErrGRPCAuthFailed
+	}
+	return &pb.AuthenticateResponse{Header: &pb.ResponseHeader{}, Token: "token"}, nil
+}
+
+func serveAuth(test *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterAuthServer(server, &authServer{})
+	go server.Serve(listener)
+	test.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestConfigureAuthentication(test *testing.T) {
+	endpoint := serveAuth(test)
+
+	for _, tc := range []struct {
+		name     string
+		config   map[string]interface{}
+		env      string
+		expected string
+	}{
+		{"valid", map[string]interface{}{"username": "root", "password": "secret"}, "", ""},
+		{"etcdctl user", map[string]interface{}{}, "root:secret", ""},
+		{"invalid", map[string]interface{}{"username": "root", "password": "wrong"}, "", "etcd authentication failed"},
+		{"config wins", map[string]interface{}{"username": "root", "password": "wrong"}, "root:secret", "etcd authentication failed"},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			os.Setenv("ETCDCTL_USER", tc.env)
+			defer os.Unsetenv("ETCDCTL_USER")
+
+			tc.config["endpoints"] = []interface{}{endpoint}
+			// The test server only implements authentication.
+			tc.config["check_health"] = false
+			d := schema.TestResourceDataRaw(test, New().Schema, tc.config)
+			meta, diags := configure(context.Background(), d)
+			if tc.expected == "" {
+				if diags.HasError() {
+					test.Fatalf("err: %v", diags)
+				}
+				meta.(*apiClient).Close()
+				return
+			}
+			if !diags.HasError() || !strings.Contains(diags[0].Summary, tc.expected) {
+				test.Fatalf("expected %q, got %v", tc.expected, diags)
+			}
+		})
+	}
+}
+
+type kvServer struct {
+	pb.UnimplementedKVServer
+}
+
+func (*kvServer) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
+	return &pb.PutResponse{Header: &pb.ResponseHeader{}}, nil
+}
+
+func (*kvServer) Range(ctx context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	return &pb.RangeResponse{Header: &pb.ResponseHeader{}}, nil
+}
+
+// serveKV starts a KV server accepting any Put and answering every Range
+// with no key, with a receive limit above the default of the client so only
+// the client limits the request size.
+func serveKV(test *testing.T, opts ...grpc.ServerOption) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	server := grpc.NewServer(append([]grpc.ServerOption{grpc.MaxRecvMsgSize(8 * 1024 * 1024)}, opts...)...)
+	pb.RegisterKVServer(server, &kvServer{})
+	go server.Serve(listener)
+	test.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestConfigureMaxCallSendMsgSize(test *testing.T) {
+	endpoint := serveKV(test)
+	value := strings.Repeat("x", 3*1024*1024)
+
+	for _, tc := range []struct {
+		name     string
+		sendSize int
+		expected codes.Code
+	}{
+		{"default", 0, codes.ResourceExhausted},
+		{"raised", 4 * 1024 * 1024, codes.OK},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+				"endpoints":              []interface{}{endpoint},
+				"check_health":           false,
+				"max_value_bytes":        0,
+				"max_call_send_msg_size": tc.sendSize,
+			})
+			meta, diags := configure(context.Background(), d)
+			if diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+			client := meta.(*apiClient)
+			defer client.Close()
+
+			_, err := client.Put(context.Background(), "/large", value)
+			if code := status.Code(err); code != tc.expected {
+				test.Fatalf("expected %s, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestCheckMessageSizes(test *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+	}{
+		{"defaults", map[string]interface{}{}, ""},
+		{"send above max value", map[string]interface{}{"max_call_send_msg_size": 2 * 1024 * 1024}, ""},
+		{"send below max value", map[string]interface{}{"max_call_send_msg_size": 1024}, "max_call_send_msg_size is too small"},
+		{"value check disabled", map[string]interface{}{"max_call_send_msg_size": 1024, "max_value_bytes": 0}, ""},
+		{"recv below send", map[string]interface{}{"max_call_send_msg_size": 4 * 1024 * 1024, "max_call_recv_msg_size": 2 * 1024 * 1024}, "must not be smaller"},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			d := schema.TestResourceDataRaw(test, New().Schema, tc.config)
+			diags := checkMessageSizes(d)
+			if tc.expected == "" {
+				if diags.HasError() {
+					test.Fatalf("err: %v", diags)
+				}
+				return
+			}
+			if !diags.HasError() || !strings.Contains(diags[0].Summary, tc.expected) {
+				test.Fatalf("expected %q, got %v", tc.expected, diags)
+			}
+		})
+	}
+}
+
+func TestConfigureHealthCheck(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":    []interface{}{"127.0.0.1:1"},
+		"dial_timeout": "200ms",
+	})
+	_, diags := configure(context.Background(), d)
+	if !diags.HasError() || diags[0].Summary != "etcd endpoints unreachable" || !strings.Contains(diags[0].Detail, "127.0.0.1:1") {
+		test.Fatalf("expected the unreachable endpoint to be reported, got %v", diags)
+	}
+}
+
+func TestConfigureBlock(test *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		block bool
+	}{
+		{"lazy", false},
+		{"block", true},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+				"endpoints":       []interface{}{"127.0.0.1:1"},
+				"dial_timeout":    "200ms",
+				"request_timeout": "200ms",
+				"check_health":    false,
+				"block":           tc.block,
+			})
+			start := time.Now()
+			meta, diags := configure(context.Background(), d)
+			if tc.block {
+				if !diags.HasError() || diags[0].Summary != "Unable to connect to etcd" || !strings.Contains(diags[0].Detail, "127.0.0.1:1") {
+					test.Fatalf("expected configure to fail on the unreachable endpoint, got %v", diags)
+				}
+				if elapsed := time.Since(start); elapsed > 2*time.Second {
+					test.Fatalf("expected configure to fail within the dial timeout, took %s", elapsed)
+				}
+				return
+			}
+			if diags.HasError() {
+				test.Fatalf("expected configure to connect lazily, got %v", diags)
+			}
+			client := meta.(*apiClient)
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			if _, err := client.Get(ctx, "/app/name"); err == nil {
+				test.Fatalf("expected the first request to fail on the unreachable endpoint")
+			}
+		})
+	}
+}
+
+func TestCheckEndpointHealth(test *testing.T) {
+	maintenance := newFakeMaintenance()
+	maintenance.addEndpoint("etcd-0:2379", 1024)
+	maintenance.addEndpoint("etcd-2:2379", 1024)
+
+	if diags := checkEndpointHealth(context.Background(), maintenance, []string{"etcd-0:2379", "etcd-2:2379"}, time.Second); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	diags := checkEndpointHealth(context.Background(), maintenance, []string{"etcd-0:2379", "etcd-1:2379", "etcd-2:2379", "etcd-3:2379"}, time.Second)
+	if !diags.HasError() {
+		test.Fatalf("expected the unreachable endpoints to be reported")
+	}
+	for endpoint, reported := range map[string]bool{"etcd-0:2379": false, "etcd-1:2379": true, "etcd-2:2379": false, "etcd-3:2379": true} {
+		if strings.Contains(diags[0].Detail, endpoint) != reported {
+			test.Fatalf("%s: expected reported=%v, got %q", endpoint, reported, diags[0].Detail)
+		}
+	}
+}
+
+func TestApplyBalancer(test *testing.T) {
+	for balancer, options := range map[string]int{"round_robin": 0, "pick_first": 2} {
+		config := etcd.Config{Endpoints: []string{"etcd-0:2379", "etcd-1:2379"}}
+		applyBalancer(&config, balancer)
+		if len(config.DialOptions) != options {
+			test.Fatalf("%s: expected %d dial options, got %d", balancer, options, len(config.DialOptions))
+		}
+	}
+}
+
+func TestConfigureBalancer(test *testing.T) {
+	for _, balancer := range []string{"round_robin", "pick_first"} {
+		d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+			"endpoints":    []interface{}{serveAuth(test), serveAuth(test)},
+			"balancer":     balancer,
+			"username":     "root",
+			"password":     "secret",
+			"check_health": false,
+		})
+		meta, diags := configure(context.Background(), d)
+		if diags.HasError() {
+			test.Fatalf("%s: err: %v", balancer, diags)
+		}
+		meta.(*apiClient).Close()
+	}
+}
+
+func TestKeepAliveSettings(test *testing.T) {
+	for _, tc := range []struct {
+		time, timeout string
+		valid         bool
+	}{
+		{"0s", "10s", true},
+		{"30s", "10s", true},
+		{"10s", "10s", false},
+		{"5s", "10s", false},
+	} {
+		d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+			"endpoints":         []interface{}{"127.0.0.1:0"},
+			"keepalive_time":    tc.time,
+			"keepalive_timeout": tc.timeout,
+		})
+		_, _, err := keepAliveSettings(d)
+		if tc.valid != (err == nil) {
+			test.Fatalf("time %s, timeout %s: expected valid=%v, got %v", tc.time, tc.timeout, tc.valid, err)
+		}
+	}
+}
+
+func TestNamespacesDoNotCollide(test *testing.T) {
+	kv := newFakeKV()
+	tenantA := newFakeClient(kv)
+	applyNamespace(tenantA.Client, "/tenant-a")
+	tenantB := newFakeClient(kv)
+	applyNamespace(tenantB.Client, "/tenant-b")
+
+	for client, value := range map[*apiClient]string{tenantA: "a", tenantB: "b"} {
+		state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+			"key":   "/config",
+			"value": value,
+		}, client)
+		if diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+		if state.ID != "/config" || state.Attributes["key"] != "/config" {
+			test.Fatalf("expected the unprefixed key in state, got %v", state)
+		}
+	}
+
+	assertKeyValue(test, kv, "/tenant-a/config", "a")
+	assertKeyValue(test, kv, "/tenant-b/config", "b")
+	if response, _ := kv.Get(context.Background(), "/config"); len(response.Kvs) != 0 {
+		test.Fatalf("expected no key outside of the namespaces")
+	}
+}
+
+// applyTestResource plans config against state and applies the result the
+// way Terraform would, returning the new state. A nil config destroys.
+// unknownValue marks a configuration value as unknown until apply, like the
+// attributes of resources not created yet, in terraform.NewResourceConfigRaw.
+const unknownValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+func applyTestResource(test *testing.T, r *schema.Resource, state *terraform.InstanceState, config map[string]interface{}, meta interface{}) (*terraform.InstanceState, diag.Diagnostics) {
+	test.Helper()
+	ctx := context.Background()
+
+	if config == nil {
+		return r.Apply(ctx, state, &terraform.InstanceDiff{Destroy: true}, meta)
+	}
+
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), meta)
+	if err != nil {
+		return state, diag.FromErr(err)
+	}
+	if diff == nil {
+		return state, nil
+	}
+	return r.Apply(ctx, state, diff, meta)
+}
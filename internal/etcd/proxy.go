@@ -0,0 +1,137 @@
+package etcd
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// proxySchemes are the proxy_url schemes: socks5 and socks5h both let the
+// proxy resolve the endpoint host names, http tunnels with CONNECT.
+var proxySchemes = []string{"socks5", "socks5h", "http"}
+
+func validateProxyURL(v interface{}, k string) ([]string, []error) {
+	if v.(string) == "" {
+		return nil, nil
+	}
+	if _, err := parseProxyURL(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q: %v", k, err)}
+	}
+	return nil, nil
+}
+
+func parseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, scheme := range proxySchemes {
+		if proxyURL.Scheme == scheme {
+			if proxyURL.Host == "" {
+				return nil, fmt.Errorf("proxy URL %q has no host", raw)
+			}
+			return proxyURL, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q, expected one of %s", proxyURL.Scheme, strings.Join(proxySchemes, ", "))
+}
+
+// proxyDialer returns a gRPC context dialer connecting to the etcd endpoints
+// through the proxy at proxyURL. Unix socket endpoints are dialed directly.
+func proxyDialer(proxyURL *url.URL) (func(ctx context.Context, addr string) (net.Conn, error), error) {
+	var dialer net.Dialer
+	connect := func(ctx context.Context, addr string) (net.Conn, error) {
+		return httpConnectDial(ctx, &dialer, proxyURL, addr)
+	}
+	if proxyURL.Scheme != "http" {
+		// proxy.FromURL takes the SOCKS5 credentials from the URL.
+		socks, err := proxy.FromURL(proxyURL, proxy.Direct)
+		if err != nil {
+			return nil, err
+		}
+		connect = func(ctx context.Context, addr string) (net.Conn, error) {
+			return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+		}
+	}
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		if strings.HasPrefix(addr, "unix:") {
+			return dialer.DialContext(ctx, "unix", strings.TrimPrefix(strings.TrimPrefix(addr, "unix:"), "//"))
+		}
+		conn, err := connect(ctx, addr)
+		if err != nil {
+			return nil, fmt.Errorf("proxy %s: %v", proxyURL.Host, err)
+		}
+		return conn, nil
+	}, nil
+}
+
+// httpConnectDial connects to the HTTP proxy at proxyURL and opens a tunnel
+// to addr through it.
+func httpConnectDial(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, err
+	}
+	// The handshake is bounded by the dial deadline, the tunnel is not.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tunnel, err := httpConnect(conn, proxyURL, addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return tunnel, nil
+}
+
+// httpConnect opens a tunnel to addr with an HTTP CONNECT request.
+func httpConnect(conn net.Conn, proxyURL *url.URL, addr string) (net.Conn, error) {
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := request.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		return nil, err
+	}
+	// The body of a successful CONNECT response is the tunnel itself, it
+	// is not closed.
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CONNECT %s: %s", addr, response.Status)
+	}
+	// Bytes of the tunnel may already have been read past the response.
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,211 @@
+package etcd
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// pipe copies between the client and the target until either side closes.
+func pipe(client, target net.Conn) {
+	go func() {
+		io.Copy(target, client)
+		target.Close()
+	}()
+	io.Copy(client, target)
+	client.Close()
+}
+
+// serveHTTPProxy starts an HTTP CONNECT proxy requiring the proxy:secret
+// credentials, sending the address of every tunnel to targets.
+func serveHTTPProxy(test *testing.T, targets chan<- string) string {
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := (&http.Request{Header: http.Header{"Authorization": r.Header["Proxy-Authorization"]}}).BasicAuth(); !ok || user != "proxy" || password != "secret" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		targets <- r.Host
+		w.WriteHeader(http.StatusOK)
+		client, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		pipe(client, target)
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	go server.Serve(listener)
+	test.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+// serveSOCKS5Proxy starts a SOCKS5 proxy requiring the proxy:secret
+// credentials, sending the address of every connection to targets.
+func serveSOCKS5Proxy(test *testing.T, targets chan<- string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	test.Cleanup(func() { listener.Close() })
+
+	handshake := func(client net.Conn) (net.Conn, error) {
+		buf := make([]byte, 256)
+		if _, err := io.ReadFull(client, buf[:2]); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(client, buf[:buf[1]]); err != nil {
+			return nil, err
+		}
+		client.Write([]byte{0x05, 0x02})
+		if _, err := io.ReadFull(client, buf[:2]); err != nil {
+			return nil, err
+		}
+		user := make([]byte, buf[1])
+		io.ReadFull(client, user)
+		io.ReadFull(client, buf[:1])
+		password := make([]byte, buf[0])
+		io.ReadFull(client, password)
+		if string(user) != "proxy" || string(password) != "secret" {
+			client.Write([]byte{0x01, 0x01})
+			return nil, fmt.Errorf("invalid credentials")
+		}
+		client.Write([]byte{0x01, 0x00})
+
+		if _, err := io.ReadFull(client, buf[:4]); err != nil {
+			return nil, err
+		}
+		var host string
+		switch buf[3] {
+		case 0x01:
+			io.ReadFull(client, buf[:4])
+			host = net.IP(buf[:4]).String()
+		case 0x03:
+			io.ReadFull(client, buf[:1])
+			name := make([]byte, buf[0])
+			io.ReadFull(client, name)
+			host = string(name)
+		default:
+			return nil, fmt.Errorf("unsupported address type %d", buf[3])
+		}
+		io.ReadFull(client, buf[:2])
+		addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+		target, err := net.Dial("tcp", addr)
+		if err != nil {
+			client.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+			return nil, err
+		}
+		targets <- addr
+		client.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return target, nil
+	}
+
+	go func() {
+		for {
+			client, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				target, err := handshake(client)
+				if err != nil {
+					client.Close()
+					return
+				}
+				pipe(client, target)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestConfigureProxy(test *testing.T) {
+	endpoint := serveKV(test)
+
+	for _, scheme := range []string{"http", "socks5"} {
+		test.Run(scheme, func(test *testing.T) {
+			targets := make(chan string, 16)
+			proxy := serveHTTPProxy(test, targets)
+			if scheme == "socks5" {
+				proxy = serveSOCKS5Proxy(test, targets)
+			}
+
+			d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+				"endpoints":    []interface{}{endpoint},
+				"check_health": false,
+				"proxy_url":    fmt.Sprintf("%s://proxy:secret@%s", scheme, proxy),
+			})
+			meta, diags := configure(context.Background(), d)
+			if diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+			client := meta.(*apiClient)
+			defer client.Close()
+
+			if _, err := client.Put(context.Background(), "/app/name", "passbase"); err != nil {
+				test.Fatalf("err: %s", err)
+			}
+			if target := <-targets; target != endpoint {
+				test.Fatalf("expected the proxy to connect to %s, got %s", endpoint, target)
+			}
+		})
+	}
+}
+
+func TestProxyDialerLongCredentials(test *testing.T) {
+	targets := make(chan string, 1)
+	proxyURL, err := parseProxyURL(fmt.Sprintf("socks5://%s:secret@%s", strings.Repeat("p", 256), serveSOCKS5Proxy(test, targets)))
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	dial, err := proxyDialer(proxyURL)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// SOCKS5 carries the username length in a single byte.
+	if conn, err := dial(ctx, serveKV(test)); err == nil {
+		conn.Close()
+		test.Fatalf("expected a username over 255 bytes to be rejected")
+	}
+	if len(targets) != 0 {
+		test.Fatalf("expected the proxy not to connect to the endpoint")
+	}
+}
+
+func TestValidateProxyURL(test *testing.T) {
+	for proxyURL, valid := range map[string]bool{
+		"":                        true,
+		"socks5://proxy:1080":     true,
+		"socks5h://user:pw@proxy": true,
+		"http://proxy:3128":       true,
+		"https://proxy:3128":      false,
+		"socks5://":               false,
+		"proxy:3128":              false,
+	} {
+		if _, errs := validateProxyURL(proxyURL, "proxy_url"); (len(errs) == 0) != valid {
+			test.Fatalf("%q: expected valid %v, got %v", proxyURL, valid, errs)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// quorumPollInterval is how long the endpoints are left alone between two
+// rounds of status requests while waiting for quorum.
+var quorumPollInterval = time.Second
+
+// errNoQuorum is returned by writes when the cluster did not regain quorum
+// within wait_timeout.
+var errNoQuorum = errors.New("etcd cluster has no quorum")
+
+// quorumGate holds back the first write of a client until the cluster has a
+// leader and a majority of its endpoints are healthy. Once quorum was seen,
+// writes go through without any further check.
+type quorumGate struct {
+	mu          sync.Mutex
+	ready       bool
+	maintenance clientv3.Maintenance
+	endpoints   []string
+	timeout     time.Duration
+}
+
+// wait blocks until the cluster has quorum, for up to timeout. Concurrent
+// writers wait for the same check, and a failed check is made again by the
+// next write.
+func (g *quorumGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ready {
+		return nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	start := time.Now()
+	for {
+		unhealthy, hasLeader := g.check(waitCtx)
+		if hasLeader && len(unhealthy) <= (len(g.endpoints)-1)/2 {
+			if time.Since(start) > quorumPollInterval {
+				logf(ctx, "INFO", "etcd cluster has quorum after waiting %s", time.Since(start).Round(time.Millisecond))
+			}
+			g.ready = true
+			return nil
+		}
+		logf(ctx, "DEBUG", "waiting for etcd quorum: leader present %v, unhealthy endpoints %v", hasLeader, unhealthy)
+
+		select {
+		case <-waitCtx.Done():
+			if !hasLeader {
+				return fmt.Errorf("%w after waiting %s: no endpoint reports a leader", errNoQuorum, g.timeout)
+			}
+			return fmt.Errorf("%w after waiting %s: %d of %d endpoints are unhealthy: %s", errNoQuorum, g.timeout, len(unhealthy), len(g.endpoints), strings.Join(unhealthy, ", "))
+		case <-time.After(quorumPollInterval):
+		}
+	}
+}
+
+// check requests the status of every endpoint, returning the endpoints that
+// did not answer or reported errors, and whether any of them knows a leader.
+func (g *quorumGate) check(ctx context.Context) ([]string, bool) {
+	unhealthy := []string{}
+	hasLeader := false
+	for _, endpoint := range g.endpoints {
+		status, err := g.maintenance.Status(ctx, endpoint)
+		if err != nil || len(status.Errors) > 0 {
+			unhealthy = append(unhealthy, endpoint)
+			continue
+		}
+		if status.Leader != 0 {
+			hasLeader = true
+		}
+	}
+	return unhealthy, hasLeader
+}
+
+// quorumKV waits for quorum before the first write of the client. Reads are
+// served as usual, a follower answers them from its own copy.
+type quorumKV struct {
+	clientv3.KV
+	gate *quorumGate
+}
+
+// applyQuorumWait holds back the writes of client until the cluster has
+// quorum, waiting up to timeout.
+func applyQuorumWait(client *clientv3.Client, timeout time.Duration) {
+	client.KV = &quorumKV{
+		KV:   client.KV,
+		gate: &quorumGate{maintenance: client, endpoints: client.Endpoints(), timeout: timeout},
+	}
+}
+
+func (kv *quorumKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	if err := kv.gate.wait(ctx); err != nil {
+		return nil, err
+	}
+	return kv.KV.Put(ctx, key, val, opts...)
+}
+
+func (kv *quorumKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	if err := kv.gate.wait(ctx); err != nil {
+		return nil, err
+	}
+	return kv.KV.Delete(ctx, key, opts...)
+}
+
+func (kv *quorumKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	if !op.IsGet() {
+		if err := kv.gate.wait(ctx); err != nil {
+			return clientv3.OpResponse{}, err
+		}
+	}
+	return kv.KV.Do(ctx, op)
+}
+
+func (kv *quorumKV) Txn(ctx context.Context) clientv3.Txn {
+	return &quorumTxn{Txn: kv.KV.Txn(ctx), ctx: ctx, gate: kv.gate}
+}
+
+// quorumTxn waits for quorum when the transaction is committed, so building
+// it does not block. Transactions are all treated as writes.
+type quorumTxn struct {
+	clientv3.Txn
+	ctx  context.Context
+	gate *quorumGate
+}
+
+func (txn *quorumTxn) If(cmps ...clientv3.Cmp) clientv3.Txn {
+	txn.Txn = txn.Txn.If(cmps...)
+	return txn
+}
+
+func (txn *quorumTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Then(ops...)
+	return txn
+}
+
+func (txn *quorumTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Else(ops...)
+	return txn
+}
+
+func (txn *quorumTxn) Commit() (*clientv3.TxnResponse, error) {
+	if err := txn.gate.wait(txn.ctx); err != nil {
+		return nil, err
+	}
+	return txn.Txn.Commit()
+}
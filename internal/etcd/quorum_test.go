@@ -0,0 +1,99 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// recoveringMaintenance reports a cluster without leader, and a majority of
+// endpoints down, for the first rounds of status requests.
+type recoveringMaintenance struct {
+	*fakeMaintenance
+	leaderlessRounds int
+	requests         int
+}
+
+func (m *recoveringMaintenance) Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	round := m.requests / len(m.status)
+	m.requests++
+	status, err := m.fakeMaintenance.Status(ctx, endpoint)
+	if err != nil || round >= m.leaderlessRounds {
+		return status, err
+	}
+	if endpoint != "etcd-0:2379" {
+		return nil, context.DeadlineExceeded
+	}
+	leaderless := *status
+	leaderless.Leader = 0
+	return &leaderless, nil
+}
+
+func TestQuorumWait(test *testing.T) {
+	interval := quorumPollInterval
+	quorumPollInterval = time.Millisecond
+	defer func() { quorumPollInterval = interval }()
+
+	ctx := context.Background()
+	maintenance := &recoveringMaintenance{fakeMaintenance: newFakeMaintenance(), leaderlessRounds: 3}
+	endpoints := []string{"etcd-0:2379", "etcd-1:2379", "etcd-2:2379"}
+	for _, endpoint := range endpoints {
+		maintenance.addEndpoint(endpoint, 1024)
+	}
+
+	kv := newFakeKV()
+	gate := &quorumGate{maintenance: maintenance, endpoints: endpoints, timeout: time.Minute}
+	client := &quorumKV{KV: kv, gate: gate}
+
+	// Reads do not wait for quorum.
+	if _, err := client.Get(ctx, "/app/name"); err != nil || maintenance.requests != 0 {
+		test.Fatalf("expected the read to be served without a status request, got %d requests (%v)", maintenance.requests, err)
+	}
+
+	if _, err := client.Put(ctx, "/app/name", "passbase"); err != nil {
+		test.Fatalf("expected the write to succeed once the cluster recovered, got %v", err)
+	}
+	assertKeyValue(test, kv, "/app/name", "passbase")
+	if expected := 4 * len(endpoints); maintenance.requests != expected {
+		test.Fatalf("expected the write to wait for %d status requests, got %d", expected, maintenance.requests)
+	}
+
+	// Quorum is only waited for once.
+	response, err := client.Txn(ctx).Then(clientv3.OpDelete("/app/name")).Commit()
+	if err != nil || !response.Succeeded || maintenance.requests != 4*len(endpoints) {
+		test.Fatalf("expected the transaction to go through without waiting, got %v, %d requests", err, maintenance.requests)
+	}
+}
+
+func TestQuorumWaitTimeout(test *testing.T) {
+	interval := quorumPollInterval
+	quorumPollInterval = time.Millisecond
+	defer func() { quorumPollInterval = interval }()
+
+	ctx := context.Background()
+	maintenance := newFakeMaintenance()
+	maintenance.addEndpoint("etcd-0:2379", 1024)
+	endpoints := []string{"etcd-0:2379", "etcd-1:2379", "etcd-2:2379"}
+
+	kv := newFakeKV()
+	client := &quorumKV{KV: kv, gate: &quorumGate{maintenance: maintenance, endpoints: endpoints, timeout: 20 * time.Millisecond}}
+
+	_, err := client.Put(ctx, "/app/name", "passbase")
+	diags := classifyEtcdError(err)
+	if !diags.HasError() || diags[0].Summary != "etcd cluster has no quorum" || !strings.Contains(diags[0].Detail, "2 of 3 endpoints are unhealthy: etcd-1:2379, etcd-2:2379") {
+		test.Fatalf("expected the missing quorum to be reported, got %v", diags)
+	}
+	if response, _ := kv.Get(ctx, "/app/name"); len(response.Kvs) != 0 {
+		test.Fatalf("expected nothing to be written without quorum")
+	}
+
+	// The next write checks again.
+	maintenance.addEndpoint("etcd-1:2379", 1024)
+	if _, err := client.Put(ctx, "/app/name", "passbase"); err != nil {
+		test.Fatalf("expected the write to succeed once a majority is healthy, got %v", err)
+	}
+	assertKeyValue(test, kv, "/app/name", "passbase")
+}
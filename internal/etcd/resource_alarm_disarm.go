@@ -0,0 +1,102 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func AlarmDisarmResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Disarms an alarm of the cluster, such as NOSPACE after a defragmentation freed space.",
+
+		CreateContext: AlarmDisarmCreate,
+		ReadContext:   AlarmDisarmRead,
+		DeleteContext: AlarmDisarmDelete,
+
+		Schema: map[string]*schema.Schema{
+			"alarm_type": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"NOSPACE", "CORRUPT"}, false),
+			},
+			"member_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateMemberID,
+				Description:  "ID of the member in hexadecimal, as printed by etcdctl. The alarm is disarmed on every member when unset.",
+			},
+			"disarmed": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the members the alarm was disarmed on.",
+			},
+		},
+	}
+}
+
+func validateMemberID(value interface{}, key string) ([]string, []error) {
+	if _, err := strconv.ParseUint(value.(string), 16, 64); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a hexadecimal member ID, got %q", key, value)}
+	}
+	return nil, nil
+}
+
+// AlarmDisarmCreate disarms the matching active alarms. No alarm matching is
+// not an error, so re-creating the resource after the alarm cleared succeeds.
+func AlarmDisarmCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	alarmType := pb.AlarmType(pb.AlarmType_value[d.Get("alarm_type").(string)])
+	var memberID uint64
+	if id := d.Get("member_id").(string); id != "" {
+		memberID, _ = strconv.ParseUint(id, 16, 64)
+	}
+
+	response, err := client.AlarmList(ctx)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to list the cluster alarms: %w", err))
+	}
+
+	disarmed := []string{}
+	for _, alarm := range response.Alarms {
+		if alarm.Alarm != alarmType || (memberID != 0 && alarm.MemberID != memberID) {
+			continue
+		}
+		logf(ctx, "DEBUG", "disarming %s alarm of member %x", alarm.Alarm, alarm.MemberID)
+		if _, err := client.AlarmDisarm(ctx, &clientv3.AlarmMember{MemberID: alarm.MemberID, Alarm: alarm.Alarm}); err != nil {
+			return classifyEtcdError(fmt.Errorf("unable to disarm the %s alarm of member %x: %w", alarm.Alarm, alarm.MemberID, err))
+		}
+		disarmed = append(disarmed, strconv.FormatUint(alarm.MemberID, 16))
+	}
+	if len(disarmed) == 0 {
+		logf(ctx, "DEBUG", "no active %s alarm to disarm", alarmType)
+	}
+
+	if err := d.Set("disarmed", disarmed); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%s/%s", alarmType, d.Get("member_id").(string)))
+	return nil
+}
+
+// AlarmDisarmRead keeps the resource in state, disarming only happens on create.
+func AlarmDisarmRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// AlarmDisarmDelete only forgets the resource, alarms cannot be re-armed.
+func AlarmDisarmDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
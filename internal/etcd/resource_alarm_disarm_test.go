@@ -0,0 +1,53 @@
+package etcd
+
+import (
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestAlarmDisarmResource(test *testing.T) {
+	maintenance := newFakeMaintenance()
+	maintenance.alarms = []*pb.AlarmMember{
+		{MemberID: 0x1a, Alarm: pb.AlarmType_NOSPACE},
+		{MemberID: 0x2b, Alarm: pb.AlarmType_NOSPACE},
+		{MemberID: 0x2b, Alarm: pb.AlarmType_CORRUPT},
+	}
+	client := newFakeClient(newFakeKV())
+	client.Maintenance = maintenance
+
+	state, diags := applyTestResource(test, AlarmDisarmResource(), nil, map[string]interface{}{
+		"alarm_type": "NOSPACE",
+		"member_id":  "2b",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["disarmed.#"] != "1" || state.Attributes["disarmed.0"] != "2b" {
+		test.Fatalf("expected the alarm of member 2b to be disarmed, got %v", state.Attributes)
+	}
+	if len(maintenance.alarms) != 2 {
+		test.Fatalf("expected the other alarms to stay active, got %v", maintenance.alarms)
+	}
+
+	state, diags = applyTestResource(test, AlarmDisarmResource(), nil, map[string]interface{}{
+		"alarm_type": "NOSPACE",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if len(maintenance.alarms) != 1 || maintenance.alarms[0].Alarm != pb.AlarmType_CORRUPT {
+		test.Fatalf("expected only the CORRUPT alarm to stay active, got %v", maintenance.alarms)
+	}
+
+	// Nothing left to disarm is not an error.
+	state, diags = applyTestResource(test, AlarmDisarmResource(), nil, map[string]interface{}{
+		"alarm_type": "NOSPACE",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["disarmed.#"] != "0" {
+		test.Fatalf("expected nothing to be disarmed, got %v", state.Attributes)
+	}
+}
@@ -43,7 +43,7 @@ func AuthenticateUserResource(ctx context.Context, d *schema.ResourceData, meta
 		d.Set("auth_status", false)
 		status, err := client.AuthStatus(ctx)
 		if err != nil {
-			return diag.FromErr(err)
+			return classifyEtcdError(err)
 		}
 		d.Set("auth_status", status.Enabled)
 		d.SetId("authentication_setting")
@@ -53,13 +53,13 @@ func AuthenticateUserResource(ctx context.Context, d *schema.ResourceData, meta
 	_, err := client.AuthEnable(ctx)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 
 	status, err := client.AuthStatus(ctx)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 
 	d.Set("auth_status", status.Enabled)
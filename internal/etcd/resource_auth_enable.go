@@ -0,0 +1,86 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func AuthEnableResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Enables authentication on the cluster while the resource exists and disables it on destroy.",
+
+		CreateContext: AuthEnableCreate,
+		ReadContext:   AuthEnableRead,
+		DeleteContext: AuthEnableDelete,
+
+		Schema: map[string]*schema.Schema{
+			"auth_revision": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func AuthEnableCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	logf(ctx, "DEBUG", "enabling authentication")
+	_, err := client.AuthEnable(ctx)
+	switch err {
+	case nil:
+	case rpctypes.ErrRootUserNotExist:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Cannot enable authentication without a root user",
+			Detail:   "etcd refuses to enable authentication until a user named \"root\" exists. Create it first, for example with an etcd_user resource this resource depends on.",
+		}}
+	case rpctypes.ErrRootRoleNotExist:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Cannot enable authentication without the root role",
+			Detail:   "etcd refuses to enable authentication until the root user has been granted the \"root\" role.",
+		}}
+	default:
+		return classifyEtcdError(err)
+	}
+
+	d.SetId("auth_enable")
+	return AuthEnableRead(ctx, d, meta)
+}
+
+func AuthEnableRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	status, err := client.AuthStatus(ctx)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	// Disabled outside of Terraform, let it be enabled again.
+	if !status.Enabled {
+		logf(ctx, "DEBUG", "authentication was disabled, removing it from state")
+		d.SetId("")
+		return nil
+	}
+	if err := d.Set("auth_revision", int(status.AuthRevision)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func AuthEnableDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	logf(ctx, "DEBUG", "disabling authentication")
+	if _, err := client.AuthDisable(ctx); err != nil {
+		return classifyEtcdError(err)
+	}
+	d.SetId("")
+	return nil
+}
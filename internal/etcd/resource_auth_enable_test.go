@@ -0,0 +1,54 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAuthEnableRequiresRootUser(test *testing.T) {
+	auth := newFakeAuth()
+	client := newFakeAuthClient(auth)
+
+	_, diags := applyTestResource(test, AuthEnableResource(), nil, map[string]interface{}{}, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "root user") {
+		test.Fatalf("expected a diagnostic about the missing root user, got %v", diags)
+	}
+}
+
+func TestAuthEnableLifecycle(test *testing.T) {
+	auth := newFakeAuth()
+	client := newFakeAuthClient(auth)
+	ctx := context.Background()
+
+	auth.UserAdd(ctx, "root", "secret")
+	auth.UserGrantRole(ctx, "root", "root")
+
+	state, diags := applyTestResource(test, AuthEnableResource(), nil, map[string]interface{}{}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	d := schema.TestResourceDataRaw(test, AuthStatusDataSource().Schema, map[string]interface{}{})
+	if diags := authStatusDataSourceRead(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if !d.Get("enabled").(bool) {
+		test.Fatalf("expected auth to be reported as enabled")
+	}
+	if got := d.Get("auth_revision").(int); got != 3 {
+		test.Fatalf("auth_revision: expected 3, got %d", got)
+	}
+
+	if _, diags := applyTestResource(test, AuthEnableResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if diags := authStatusDataSourceRead(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if d.Get("enabled").(bool) {
+		test.Fatalf("expected auth to be disabled after destroy")
+	}
+}
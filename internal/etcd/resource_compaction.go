@@ -0,0 +1,124 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func CompactionResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Compacts the history of the cluster up to a revision.",
+
+		CreateContext: CompactionCreate,
+		ReadContext:   CompactionRead,
+		UpdateContext: CompactionUpdate,
+		DeleteContext: CompactionDelete,
+
+		Schema: map[string]*schema.Schema{
+			"revision": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				// History cannot be compacted backwards, a revision that
+				// has not advanced past the stored one is a no-op.
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					oldRev, err := strconv.Atoi(old)
+					if err != nil || old == "" {
+						return false
+					}
+					newRev, err := strconv.Atoi(new)
+					return err == nil && newRev <= oldRev
+				},
+				Description: "Revision to compact up to, the current revision of the cluster when unset.",
+			},
+			"physical": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Wait until the compacted entries are physically removed from the backend.",
+			},
+		},
+	}
+}
+
+// compact compacts the cluster up to rev. A revision that was already
+// compacted, by Terraform or someone else, is reported as a warning.
+func compact(ctx context.Context, client *apiClient, rev int64, physical bool) diag.Diagnostics {
+	var opts []clientv3.CompactOption
+	if physical {
+		opts = append(opts, clientv3.WithCompactPhysical())
+	}
+
+	logf(ctx, "DEBUG", "compacting up to revision %d", rev)
+	_, err := client.Compact(ctx, rev, opts...)
+	if err == rpctypes.ErrCompacted {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Revision already compacted",
+			Detail:   fmt.Sprintf("The history of the cluster was already compacted at or past revision %d, nothing was done.", rev),
+		}}
+	}
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	return nil
+}
+
+func CompactionCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	rev := int64(d.Get("revision").(int))
+	if rev == 0 {
+		// Any key will do, only the header revision is needed.
+		response, err := client.Get(ctx, "compaction", clientv3.WithCountOnly())
+		if err != nil {
+			return classifyEtcdError(err)
+		}
+		rev = response.Header.Revision
+	}
+
+	diags := compact(ctx, client, rev, d.Get("physical").(bool))
+	if diags.HasError() {
+		return diags
+	}
+	d.SetId(strconv.FormatInt(rev, 10))
+	d.Set("revision", int(rev))
+	return diags
+}
+
+func CompactionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// CompactionUpdate compacts up to a revision that advanced past the stored
+// one, or only records a change of physical.
+func CompactionUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	if !d.HasChange("revision") {
+		return nil
+	}
+	rev := d.Get("revision").(int)
+	diags := compact(ctx, client, int64(rev), d.Get("physical").(bool))
+	if diags.HasError() {
+		return diags
+	}
+	d.SetId(strconv.Itoa(rev))
+	return diags
+}
+
+// CompactionDelete only forgets the resource, compacted history is gone.
+func CompactionDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,73 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestCompactionResource(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	for i := 0; i < 5; i++ {
+		kv.Put(ctx, "/app/counter", fmt.Sprint(i))
+	}
+
+	r := CompactionResource()
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{"revision": 3}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if kv.compactRev != 3 {
+		test.Fatalf("expected the history to be compacted at 3, got %d", kv.compactRev)
+	}
+
+	// Re-applying an older revision does nothing.
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(map[string]interface{}{"revision": 2}), client)
+	if err != nil || diff != nil && !diff.Empty() {
+		test.Fatalf("expected no diff for an older revision, got %v (%v)", diff, err)
+	}
+
+	state, diags = applyTestResource(test, r, state, map[string]interface{}{"revision": 5}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if kv.compactRev != 5 || state.ID != "5" {
+		test.Fatalf("expected the history to be compacted at 5, got %d (%s)", kv.compactRev, state.ID)
+	}
+
+	if _, diags := applyTestResource(test, r, state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+}
+
+func TestCompactionResourceCurrentRevision(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/app/name", "passbase")
+	kv.Put(ctx, "/app/name", "awesome")
+
+	state, diags := applyTestResource(test, CompactionResource(), nil, map[string]interface{}{}, newFakeClient(kv))
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if kv.compactRev != 3 || state.Attributes["revision"] != "3" {
+		test.Fatalf("expected the history to be compacted at the current revision 3, got %d", kv.compactRev)
+	}
+}
+
+func TestCompactionResourceAlreadyCompacted(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/app/name", "passbase")
+	kv.Compact(ctx, 2)
+
+	_, diags := applyTestResource(test, CompactionResource(), nil, map[string]interface{}{"revision": 2}, newFakeClient(kv))
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		test.Fatalf("expected a warning for an already compacted revision, got %v", diags)
+	}
+}
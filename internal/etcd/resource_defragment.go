@@ -0,0 +1,59 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func DefragmentResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Defragments the backend database of a member to release the space freed by compaction.",
+
+		CreateContext: DefragmentCreate,
+		ReadContext:   DefragmentRead,
+		DeleteContext: DefragmentDelete,
+
+		Schema: map[string]*schema.Schema{
+			"endpoint": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"triggers": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that defragment the member again when they change.",
+			},
+		},
+	}
+}
+
+func DefragmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	endpoint := d.Get("endpoint").(string)
+	logf(ctx, "DEBUG", "defragmenting endpoint %s", endpoint)
+	if _, err := client.Defragment(ctx, endpoint); err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to defragment endpoint %s: %w", endpoint, err))
+	}
+	d.SetId(endpoint)
+	return nil
+}
+
+// DefragmentRead keeps the resource in state, defragmenting only happens on
+// create.
+func DefragmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// DefragmentDelete only forgets the resource.
+func DefragmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
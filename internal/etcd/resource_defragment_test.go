@@ -0,0 +1,52 @@
+package etcd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefragmentResource(test *testing.T) {
+	maintenance := newFakeMaintenance()
+	maintenance.addEndpoint("10.0.0.1:2379", 1024)
+	client := newFakeClient(newFakeKV())
+	client.Maintenance = maintenance
+
+	config := map[string]interface{}{
+		"endpoint": "10.0.0.1:2379",
+		"triggers": map[string]interface{}{"compaction": "100"},
+	}
+	state, diags := applyTestResource(test, DefragmentResource(), nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	// Re-applying the same configuration does not defragment again.
+	if state, diags = applyTestResource(test, DefragmentResource(), state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if !reflect.DeepEqual(maintenance.defragmented, []string{"10.0.0.1:2379"}) {
+		test.Fatalf("expected a single defragmentation, got %v", maintenance.defragmented)
+	}
+
+	// Changing the triggers replaces the resource, which defragments again.
+	config["triggers"] = map[string]interface{}{"compaction": "200"}
+	if state, diags = applyTestResource(test, DefragmentResource(), state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if len(maintenance.defragmented) != 2 {
+		test.Fatalf("expected a second defragmentation, got %v", maintenance.defragmented)
+	}
+}
+
+func TestDefragmentResourceUnreachable(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.Maintenance = newFakeMaintenance()
+
+	_, diags := applyTestResource(test, DefragmentResource(), nil, map[string]interface{}{
+		"endpoint": "10.0.0.9:2379",
+	}, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "10.0.0.9:2379") {
+		test.Fatalf("expected an error naming the endpoint, got %v", diags)
+	}
+}
@@ -0,0 +1,173 @@
+package etcd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func KeepAliveManagerResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Keeps a set of leases alive for as long as Terraform runs the provider, using a single background manager.",
+
+		CreateContext: KeepAliveManagerCreate,
+		ReadContext:   KeepAliveManagerRead,
+		UpdateContext: KeepAliveManagerUpdate,
+		DeleteContext: KeepAliveManagerDelete,
+
+		Schema: map[string]*schema.Schema{
+			"lease_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"leases": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Keep-alive status of every lease.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"lease_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ttl": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"lost": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func expandLeaseIDs(set *schema.Set) ([]clientv3.LeaseID, error) {
+	ids := []clientv3.LeaseID{}
+	for _, raw := range set.List() {
+		id, err := parseLeaseID(raw.(string))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// keepAliveManagerOwner identifies the manager d among the owners of the
+// leases it keeps alive, which etcd_lease and other managers may share.
+func keepAliveManagerOwner(d *schema.ResourceData) string {
+	return "etcd_keepalive_manager." + d.Id()
+}
+
+func KeepAliveManagerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	d.SetId(hex.EncodeToString(buf))
+
+	if diags := keepLeasesAlive(d, meta.(*apiClient)); diags.HasError() {
+		return diags
+	}
+	return KeepAliveManagerRead(ctx, d, meta)
+}
+
+func keepLeasesAlive(d *schema.ResourceData, client *apiClient) diag.Diagnostics {
+	ids, err := expandLeaseIDs(d.Get("lease_ids").(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, id := range ids {
+		if err := client.keepAlives.add(id, keepAliveManagerOwner(d)); err != nil {
+			return diag.Errorf("unable to keep lease %s alive: %v", formatLeaseID(id), err)
+		}
+	}
+	return nil
+}
+
+func KeepAliveManagerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := meta.(*apiClient)
+
+	ids, err := expandLeaseIDs(d.Get("lease_ids").(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	leases := []interface{}{}
+	for _, id := range ids {
+		response, err := client.TimeToLive(ctx, id)
+		if err != nil {
+			return classifyEtcdError(err)
+		}
+
+		_, lost, tracked := client.keepAlives.status(id)
+		lost = lost || response.TTL <= 0
+		if !lost && !tracked {
+			// Every Terraform run starts a new provider process, resume
+			// keeping the lease alive for this one.
+			if err := client.keepAlives.add(id, keepAliveManagerOwner(d)); err != nil {
+				return diag.Errorf("unable to keep lease %s alive: %v", formatLeaseID(id), err)
+			}
+		}
+		if lost {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Lease was lost",
+				Detail:   fmt.Sprintf("The lease %s expired and can no longer be kept alive.", formatLeaseID(id)),
+			})
+		}
+
+		leases = append(leases, map[string]interface{}{
+			"lease_id": formatLeaseID(id),
+			"ttl":      response.TTL,
+			"lost":     lost,
+		})
+	}
+
+	if err := d.Set("leases", leases); err != nil {
+		return diag.FromErr(err)
+	}
+	return diags
+}
+
+func KeepAliveManagerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	old, _ := d.GetChange("lease_ids")
+	previous, err := expandLeaseIDs(old.(*schema.Set).Difference(d.Get("lease_ids").(*schema.Set)))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, id := range previous {
+		client.keepAlives.remove(id, keepAliveManagerOwner(d))
+	}
+
+	if diags := keepLeasesAlive(d, client); diags.HasError() {
+		return diags
+	}
+	return KeepAliveManagerRead(ctx, d, meta)
+}
+
+func KeepAliveManagerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	ids, err := expandLeaseIDs(d.Get("lease_ids").(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, id := range ids {
+		client.keepAlives.remove(id, keepAliveManagerOwner(d))
+	}
+	d.SetId("")
+	return nil
+}
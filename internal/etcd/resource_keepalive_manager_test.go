@@ -0,0 +1,122 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestKeepAliveManagerRefreshesLeases(test *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	client.keepAlives = newKeepAliveManager(ctx, lease)
+	defer client.keepAlives.close()
+
+	ids := []interface{}{}
+	for i := 0; i < 3; i++ {
+		granted, _ := lease.Grant(ctx, 60)
+		// Pretend most of the TTL already elapsed.
+		lease.leases[granted.ID].ttl = 5
+		ids = append(ids, formatLeaseID(granted.ID))
+	}
+
+	d := schema.TestResourceDataRaw(test, KeepAliveManagerResource().Schema, map[string]interface{}{
+		"lease_ids": ids,
+	})
+	if diags := KeepAliveManagerCreate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	for i := 0; i < 3; i++ {
+		if ttl := d.Get(fmt.Sprintf("leases.%d.ttl", i)).(int); ttl != 60 {
+			test.Fatalf("expected the TTL of lease %d to be reset to 60, got %d", i, ttl)
+		}
+	}
+
+	lost, _ := parseLeaseID(ids[0].(string))
+	lease.expire(lost)
+
+	diags := KeepAliveManagerRead(ctx, d, client)
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		test.Fatalf("expected a warning about the lost lease, got %v", diags)
+	}
+	lostCount := 0
+	for _, raw := range d.Get("leases").([]interface{}) {
+		if raw.(map[string]interface{})["lost"].(bool) {
+			lostCount++
+		}
+	}
+	if lostCount != 1 {
+		test.Fatalf("expected exactly one lost lease, got %d", lostCount)
+	}
+
+	if diags := KeepAliveManagerDelete(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	for _, raw := range ids {
+		id, _ := parseLeaseID(raw.(string))
+		if _, _, tracked := client.keepAlives.status(id); tracked {
+			test.Fatalf("expected lease %s to no longer be kept alive", raw)
+		}
+	}
+}
+
+func TestKeepAliveManagerSharedLeases(test *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	client.keepAlives = newKeepAliveManager(ctx, lease)
+	defer client.keepAlives.close()
+
+	leaseState, diags := applyTestResource(test, LeaseResource(), nil, map[string]interface{}{
+		"ttl":        60,
+		"keep_alive": true,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	id, _ := parseLeaseID(leaseState.ID)
+
+	managers := []*schema.ResourceData{}
+	for i := 0; i < 2; i++ {
+		d := schema.TestResourceDataRaw(test, KeepAliveManagerResource().Schema, map[string]interface{}{
+			"lease_ids": []interface{}{leaseState.ID},
+		})
+		if diags := KeepAliveManagerCreate(ctx, d, client); diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+		managers = append(managers, d)
+	}
+	if managers[0].Id() == managers[1].Id() {
+		test.Fatalf("expected every manager to get its own ID, got %q twice", managers[0].Id())
+	}
+
+	// The lease is kept alive until every resource keeping it alive is gone.
+	if _, diags := applyTestResource(test, LeaseResource(), leaseState, map[string]interface{}{
+		"ttl":        60,
+		"keep_alive": false,
+	}, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	for i, d := range managers {
+		if _, _, tracked := client.keepAlives.status(id); !tracked {
+			test.Fatalf("expected the lease to be kept alive by %d managers", len(managers)-i)
+		}
+		if diags := KeepAliveManagerDelete(ctx, d, client); diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+	}
+	if _, _, tracked := client.keepAlives.status(id); tracked {
+		test.Fatalf("expected the lease to no longer be kept alive")
+	}
+}
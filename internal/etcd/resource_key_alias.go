@@ -0,0 +1,166 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func KeyAliasResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Keeps `dest_key` in sync with the current value of `source_key`, copying it on every apply where the source changed.",
+
+		CreateContext: KeyAliasCreate,
+		ReadContext:   KeyAliasRead,
+		UpdateContext: KeyAliasUpdate,
+		DeleteContext: KeyAliasDelete,
+		CustomizeDiff: keyAliasCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"source_key": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"dest_key": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"value": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Value currently held by the destination key.",
+			},
+			"updated": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the last apply wrote the destination key.",
+			},
+		},
+	}
+}
+
+func getSourceValue(ctx context.Context, client *apiClient, key string) (string, error) {
+	response, err := client.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if len(response.Kvs) == 0 {
+		return "", fmt.Errorf("source key %q does not exist", key)
+	}
+	return string(response.Kvs[0].Value), nil
+}
+
+// keyAliasCustomizeDiff plans an update whenever the source value drifted
+// from the value copied to the destination. A source that cannot be read at
+// plan time leaves the value to the apply, which reports the error.
+func keyAliasCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+	if !d.NewValueKnown("source_key") {
+		return keyAliasUnknownValue(d)
+	}
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	sourceKey := d.Get("source_key").(string)
+	source, err := getSourceValue(ctx, client, sourceKey)
+	if err != nil {
+		logf(ctx, "DEBUG", "unable to read source key %q at plan time: %v", sourceKey, err)
+		return keyAliasUnknownValue(d)
+	}
+	if source != d.Get("value").(string) {
+		if err := d.SetNew("value", source); err != nil {
+			return err
+		}
+		return d.SetNewComputed("updated")
+	}
+	if d.HasChange("source_key") {
+		return d.SetNewComputed("updated")
+	}
+	return nil
+}
+
+func keyAliasUnknownValue(d *schema.ResourceDiff) error {
+	if err := d.SetNewComputed("value"); err != nil {
+		return err
+	}
+	return d.SetNewComputed("updated")
+}
+
+func syncKeyAlias(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	sourceKey := d.Get("source_key").(string)
+	destKey := d.Get("dest_key").(string)
+
+	source, err := getSourceValue(ctx, client, sourceKey)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	response, err := client.Get(ctx, destKey)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	updated := len(response.Kvs) == 0 || string(response.Kvs[0].Value) != source
+	if updated {
+		logf(ctx, "DEBUG", "copying the value of %q to %q", sourceKey, destKey)
+		if _, err := client.Put(ctx, destKey, source); err != nil {
+			return classifyEtcdError(err)
+		}
+	}
+
+	d.SetId(destKey)
+	if err := d.Set("updated", updated); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("value", source); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func KeyAliasCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return syncKeyAlias(ctx, d, meta)
+}
+
+func KeyAliasUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return syncKeyAlias(ctx, d, meta)
+}
+
+func KeyAliasRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	response, err := client.Get(ctx, d.Id())
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if len(response.Kvs) == 0 {
+		logf(ctx, "DEBUG", "alias %q was deleted, removing it from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err := d.Set("value", string(response.Kvs[0].Value)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func KeyAliasDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	logf(ctx, "DEBUG", "deleting alias %q", d.Id())
+	if _, err := client.Delete(ctx, d.Id()); err != nil {
+		return classifyEtcdError(err)
+	}
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,87 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestKeyAliasFollowsSource(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/certs/2021", "v1")
+
+	d := schema.TestResourceDataRaw(test, KeyAliasResource().Schema, map[string]interface{}{
+		"source_key": "/certs/2021",
+		"dest_key":   "/certs/current",
+	})
+	if diags := KeyAliasCreate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/certs/current", "v1")
+	if !d.Get("updated").(bool) {
+		test.Fatalf("expected the create to update the destination")
+	}
+
+	kv.Put(ctx, "/certs/2021", "v2")
+	if diags := KeyAliasUpdate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/certs/current", "v2")
+	if !d.Get("updated").(bool) || d.Get("value").(string) != "v2" {
+		test.Fatalf("expected the destination to follow the source, got %v", d.State())
+	}
+
+	if diags := KeyAliasUpdate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if d.Get("updated").(bool) {
+		test.Fatalf("expected no write when the source did not change")
+	}
+}
+
+func TestKeyAliasMissingSource(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, KeyAliasResource().Schema, map[string]interface{}{
+		"source_key": "/certs/missing",
+		"dest_key":   "/certs/current",
+	})
+	if diags := KeyAliasCreate(context.Background(), d, newFakeClient(newFakeKV())); !diags.HasError() {
+		test.Fatalf("expected an error for a missing source key")
+	}
+}
+
+func TestKeyAliasPlanWithoutReadableSource(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/certs/2021", "v1")
+
+	r := KeyAliasResource()
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"source_key": "/certs/2021",
+		"dest_key":   "/certs/current",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	kv.Delete(ctx, "/certs/2021")
+	for name, source := range map[string]string{
+		"deleted source": "/certs/2021",
+		"unknown source": unknownValue,
+	} {
+		diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"source_key": source,
+			"dest_key":   "/certs/current",
+		}), client)
+		if err != nil {
+			test.Fatalf("%s: expected the plan to succeed, got %v", name, err)
+		}
+		if attribute := diff.Attributes["value"]; attribute == nil || !attribute.NewComputed {
+			test.Fatalf("%s: expected value to be unknown until apply, got %#v", name, attribute)
+		}
+	}
+}
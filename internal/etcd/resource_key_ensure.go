@@ -0,0 +1,109 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/clientv3util"
+)
+
+func KeyEnsureResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Seeds a key with a default value only if it is missing, and never overwrites it.",
+
+		CreateContext: KeyEnsureCreate,
+		ReadContext:   KeyEnsureRead,
+		UpdateContext: KeyEnsureRead,
+		DeleteContext: KeyEnsureDelete,
+
+		Schema: map[string]*schema.Schema{
+			"key": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"default": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Value written when the key is missing. Changing it does not write the key.",
+			},
+			"value": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Value the key holds, whoever wrote it.",
+			},
+			"created": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the key was missing and written with `default` when the resource was created.",
+			},
+		},
+	}
+}
+
+// KeyEnsureCreate writes the default value unless the key exists, reading
+// the existing value in the same transaction otherwise.
+func KeyEnsureCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	key := d.Get("key").(string)
+	value := d.Get("default").(string)
+
+	response, err := client.Txn(ctx).
+		If(clientv3util.KeyMissing(key)).
+		Then(clientv3.OpPut(key, value)).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if response.Succeeded {
+		logf(ctx, "DEBUG", "key %q was missing, created it with the default value", key)
+	} else {
+		logf(ctx, "DEBUG", "key %q already exists, leaving it untouched", key)
+		value = string(response.Responses[0].GetResponseRange().Kvs[0].Value)
+	}
+
+	if err := d.Set("created", response.Succeeded); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("value", value); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(key)
+	return nil
+}
+
+// KeyEnsureRead surfaces the current value. A key deleted outside of
+// Terraform is removed from state, so the next apply seeds it again.
+func KeyEnsureRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	response, err := client.Get(ctx, d.Id())
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if len(response.Kvs) == 0 {
+		logf(ctx, "DEBUG", "key %q no longer exists, removing it from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err := d.Set("key", d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("value", string(response.Kvs[0].Value)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// KeyEnsureDelete only forgets the resource, the key may have existed before
+// and is left untouched.
+func KeyEnsureDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
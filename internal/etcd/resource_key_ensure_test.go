@@ -0,0 +1,61 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyEnsureCreatesMissingKey(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+
+	r := KeyEnsureResource()
+	config := map[string]interface{}{
+		"key":     "/app/config",
+		"default": "seed",
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", "seed")
+	if state.Attributes["created"] != "true" || state.Attributes["value"] != "seed" {
+		test.Fatalf("expected the key to be created with the default, got %v", state.Attributes)
+	}
+
+	// Changes made outside of Terraform are surfaced, never overwritten.
+	kv.Put(ctx, "/app/config", "edited")
+	config["default"] = "other"
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", "edited")
+	if state.Attributes["value"] != "edited" {
+		test.Fatalf("expected the edited value in state, got %v", state.Attributes)
+	}
+
+	// Destroying the resource leaves the key in place.
+	if _, diags = applyTestResource(test, r, state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", "edited")
+}
+
+func TestKeyEnsureLeavesExistingKey(test *testing.T) {
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(context.Background(), "/app/config", "existing")
+
+	state, diags := applyTestResource(test, KeyEnsureResource(), nil, map[string]interface{}{
+		"key":     "/app/config",
+		"default": "seed",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", "existing")
+	if state.Attributes["created"] != "false" || state.Attributes["value"] != "existing" {
+		test.Fatalf("expected the existing value to be surfaced, got %v", state.Attributes)
+	}
+}
@@ -1,14 +1,22 @@
 package etcd
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-
-	//"strconv"
-	//"time"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/clientv3util"
@@ -20,8 +28,14 @@ func KvResource() *schema.Resource {
 
 		CreateContext: KvResourceCreate,
 		ReadContext:   KvResourceRead,
-		//UpdateContext: resourceUpdate,
+		UpdateContext: KvResourceUpdate,
 		DeleteContext: KvResourceDelete,
+		CustomizeDiff: kvResourceCustomizeDiff,
+
+		// The import ID is the etcd key.
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"id": &schema.Schema{
@@ -29,83 +43,898 @@ func KvResource() *schema.Resource {
 				Computed: true,
 			},
 			"key": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Key to write. Changing it moves the value to the new key in a single transaction.",
+			},
+			"endpoints": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Endpoints of the cluster holding the key, instead of the provider endpoints.",
 			},
 			"value": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ExactlyOneOf:     []string{"value", "sensitive_value", "value_file", "value_template"},
+				DiffSuppressFunc: suppressEquivalentValue,
+			},
+			"sensitive_value": &schema.Schema{
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressEquivalentValue,
+				Description:      "Value of the key, redacted from plan and apply output.",
+			},
+			"value_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of a local file to write the content of instead of `value`, read at apply time.",
+			},
+			"value_template": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateValueTemplate,
+				Description:  "Go template rendered at apply time instead of `value`, where `key \"/path\"` is replaced by the value of another key.",
+			},
+			"rendered_value": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Value rendered from `value_template`.",
+			},
+			"value_hash": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 of the value when it is read from `value_file`, a change of the file updates the key.",
+			},
+			"sensitive": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Redact the value from the provider logs.",
+			},
+			"compress": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Store the value gzip compressed in etcd, the value in state stays uncompressed.",
+			},
+			"value_format": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"json"}, false),
+				Description:  "Set to `json` to compare values as JSON documents, ignoring formatting differences.",
+			},
+			"overwrite": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Overwrite the value of a key that already exists on create instead of failing.",
+			},
+			"create_only_with_lease": &schema.Schema{
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"overwrite"},
+				Description:   "Only create the key if no one holds it yet, bound to `lease_ttl` or `lease_id`, like a leader election.",
+			},
+			"delete_protection": deleteProtectionSchema(),
+			"warn_on_missing_delete": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Emit a warning on destroy when the key was already deleted outside of Terraform.",
+			},
+			"log_deleted_value": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Log the value the key held when it is deleted, at `INFO`, as a record to restore it from.",
+			},
+			"verify_write": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Read the key back after every create and update and fail when etcd does not hold the value written.",
+			},
+			"keep_value_on_lease_change": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When only `lease_id` changes, attach the key to the new lease without writing the value, so the value in etcd is kept as is.",
+			},
+			"lease_ttl": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Attach the key to a new lease with this TTL in seconds, the key is deleted when the lease expires.",
+			},
+			"lease_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"lease_ttl"},
+				ValidateFunc:  validateLeaseID,
+				Description:   "Attach the key to an existing lease, managed outside of this resource. Set to the ID of the lease granted for `lease_ttl` otherwise. Removing it keeps the key on its lease, set it to `0` to detach the key.",
+			},
+			"if_mod_revision": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Only update the value if the key was last modified at this revision.",
+			},
+			"if_value": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"if_mod_revision"},
+				Description:   "Only update the value if the key currently holds this value.",
+			},
+			"mod_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Revision of the last modification of the key.",
+			},
+			"create_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Cluster revision at which the key was created.",
+			},
+			"version": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of times the key was written since it was created.",
+			},
+			"lease": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the lease attached to the key, `0` when there is none.",
+			},
+			"served_by": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Address of the etcd endpoint that handled the last write of the key.",
 			},
 		},
 	}
 }
 
-func KvResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
+// suppressEquivalentValue ignores value changes that only reformat the JSON
+// document when value_format is json.
+func suppressEquivalentValue(k, old, new string, d *schema.ResourceData) bool {
+	return d.Get("value_format").(string) == "json" && equivalentJSON(old, new)
+}
+
+func equivalentJSON(old, new string) bool {
+	var oldDoc, newDoc interface{}
+	if err := json.Unmarshal([]byte(old), &oldDoc); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(new), &newDoc); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(oldDoc, newDoc)
+}
+
+// kvResourceCustomizeDiff rejects invalid JSON values at plan time and marks
+// mod_revision and version as unknown when the value is about to be written,
+// since the write creates a new revision.
+func kvResourceCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// Keys already managed are left alone when a rule is turned on later.
+	if client, ok := meta.(*apiClient); ok && d.NewValueKnown("key") && (d.Id() == "" || d.HasChange("key")) {
+		if err := validateKey(client.keyValidation, d.Get("key").(string)); err != nil {
+			return err
+		}
+	}
+	attribute := valueAttribute(d)
+	if d.Get("value_format").(string) == "json" && d.NewValueKnown(attribute) {
+		if value := d.Get(attribute).(string); !json.Valid([]byte(value)) {
+			return fmt.Errorf("value of key %q is not valid JSON but value_format is json", d.Get("key").(string))
+		}
+	}
+	value, valueKnown := d.Get(attribute).(string), d.NewValueKnown(attribute)
+	if text := d.Get("value_template").(string); text != "" {
+		// A change of the referenced keys updates the key. Rendering fails
+		// on keys created later in the same apply, rendering happens again
+		// on apply anyway.
+		rendered, err := planValueTemplate(ctx, d, meta, text)
+		if err == nil {
+			value, valueKnown = rendered, true
+		}
+		if d.Id() != "" && (err != nil || rendered != d.Get("rendered_value").(string)) {
+			if err := d.SetNewComputed("rendered_value"); err != nil {
+				return err
+			}
+		}
+	}
+	if path := d.Get("value_file").(string); path != "" && d.NewValueKnown("value_file") {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read value_file of key %q: %v", d.Get("key").(string), err)
+		}
+		if hash := valueHash(content); hash != d.Get("value_hash").(string) {
+			if err := d.SetNew("value_hash", hash); err != nil {
+				return err
+			}
+		}
+		value, valueKnown = string(content), true
+	} else if path != "" {
+		valueKnown = false
+	}
+	if client, ok := meta.(*apiClient); ok && client.maxValueBytes > 0 && valueKnown && d.NewValueKnown("compress") {
+		if err := checkValueSize(d, value, client.maxValueBytes); err != nil {
+			return err
+		}
+	}
+	// A reformatted JSON document is not written again.
+	old, new := d.GetChange(attribute)
+	valueChanged := d.HasChange(attribute) && !(d.Get("value_format").(string) == "json" && equivalentJSON(old.(string), new.(string)))
+	// A moved key is created anew, with its own create revision.
+	if d.Id() != "" && d.HasChange("key") {
+		if err := d.SetNewComputed("create_revision"); err != nil {
+			return err
+		}
+	}
+	if d.Id() != "" && (valueChanged || d.HasChange("value_hash") || d.HasChange("lease_id") || d.HasChange("compress") || d.HasChange("key")) {
+		if err := d.SetNewComputed("mod_revision"); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("served_by"); err != nil {
+			return err
+		}
+		return d.SetNewComputed("version")
+	}
+	return nil
+}
 
-	client := meta.(*apiClient).Client
+func KvResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, diags := resourceClient(ctx, d, meta)
+	if diags.HasError() {
+		return diags
+	}
+	ctx = client.startOperation(ctx)
 
 	key := d.Get("key").(string)
-	value := d.Get("value").(string)
+	// An empty value is a valid etcd value, distinct from a missing key.
+	value, err := desiredValue(ctx, client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	stored, err := encodeValue(d, value)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	kvc := clientv3.NewKV(client)
+	logf(ctx, "DEBUG", "creating key %q", key)
+	start := time.Now()
 
-	_, err := kvc.Txn(ctx).If(clientv3util.KeyMissing(key)).Then(clientv3.OpPut(key, value)).Commit()
+	// A key held without a lease would never be released to the others.
+	firstWriteWins := d.Get("create_only_with_lease").(bool)
+	external, _ := parseLeaseID(d.Get("lease_id").(string))
+	if _, ttl := d.GetOk("lease_ttl"); firstWriteWins && !ttl && external == clientv3.NoLease {
+		return diag.Errorf("create_only_with_lease of key %q requires lease_ttl or lease_id", key)
+	}
 
-	if err != nil {
-		switch err {
-		case context.Canceled:
-			errmsg := fmt.Errorf("ctx is canceled by another routine: %v", err)
-			return diag.FromErr(errmsg)
-		case context.DeadlineExceeded:
-			errmsg := fmt.Errorf("ctx is attached with a deadline is exceeded: %v", err)
-			return diag.FromErr(errmsg)
-		case rpctypes.ErrEmptyKey:
-			errmsg := fmt.Errorf("client-side error: %v", err)
-			return diag.FromErr(errmsg)
-		default:
-			errmsg := fmt.Errorf("bad cluster endpoints, which are not etcd servers: %v", err)
-			return diag.FromErr(errmsg)
+	var opts []clientv3.OpOption
+	var leaseID clientv3.LeaseID
+	if ttl, ok := d.GetOk("lease_ttl"); ok {
+		lease, err := client.Grant(ctx, int64(ttl.(int)))
+		if err != nil {
+			return classifyEtcdError(err)
+		}
+		leaseID = lease.ID
+		opts = append(opts, clientv3.WithLease(leaseID))
+	} else if d.Get("lease_id").(string) != "" {
+		if diags := checkLease(ctx, client, external); diags.HasError() {
+			return diags
 		}
+		opts = append(opts, clientv3.WithLease(external))
+	}
 
+	writeCtx, served := withServedBy(ctx)
+	txn := client.Txn(writeCtx)
+	if !d.Get("overwrite").(bool) {
+		txn = txn.If(clientv3util.KeyMissing(key))
+	}
+	txn = txn.Then(clientv3.OpPut(key, stored, opts...))
+	if firstWriteWins {
+		txn = txn.Else(clientv3.OpGet(key))
+	}
+	response, err := txn.Commit()
+
+	if err != nil || !response.Succeeded {
+		if leaseID != clientv3.NoLease {
+			client.Revoke(ctx, leaseID)
+		}
+	}
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if !response.Succeeded && firstWriteWins {
+		holder := "another client"
+		if kvs := response.Responses[0].GetResponseRange().Kvs; len(kvs) > 0 && kvs[0].Lease != 0 {
+			holder = fmt.Sprintf("lease %s", formatLeaseID(clientv3.LeaseID(kvs[0].Lease)))
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Key already held",
+			Detail:   fmt.Sprintf("The key %q is already held by %s, another contender created it first. It is released when the holder deletes it or its lease expires.", key, holder),
+		}}
 	}
+	// The key was written outside of Terraform, do not adopt it silently.
+	if !response.Succeeded {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Key already exists",
+			Detail:   fmt.Sprintf("The key %q already exists in etcd and was left unchanged. Import it with `terraform import` to manage it with Terraform, or set overwrite to replace its value.", key),
+		}}
+	}
+	logKeyOperation(ctx, "DEBUG", "create", key, response.Header.Revision, start, map[string]interface{}{
+		"value": loggedValue(d, value),
+	})
 	d.SetId(key)
+	d.Set("served_by", served.endpoint())
+	if leaseID != clientv3.NoLease {
+		d.Set("lease_id", formatLeaseID(leaseID))
+	}
+	if diags := verifyWrite(ctx, client, d, key, stored, response.Header.Revision); diags.HasError() {
+		return diags
+	}
 
-	return diags
+	// Read the key back so the metadata is known right after create.
+	return append(diags, KvResourceRead(ctx, d, meta)...)
 }
 
 func KvResourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient)
+	client, diags := resourceClient(ctx, d, meta)
+	if diags.HasError() {
+		return diags
+	}
+	ctx = client.startOperation(ctx)
 
-	key := d.Get("key").(string)
+	key := d.Id()
 
+	logf(ctx, "TRACE", "reading key %q", key)
+	start := time.Now()
 	response, err := client.Get(ctx, key)
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 
 	}
-	d.Set("key", response.Kvs)
+
+	// The key was deleted outside of Terraform, let it be recreated unless
+	// on_external_delete asks to stop. A key holding an empty value is still
+	// returned and stays managed.
+	if len(response.Kvs) == 0 {
+		if client.onExternalDelete == "error" && !leaseExpired(ctx, client, d) {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "Key deleted outside of Terraform",
+				Detail:   fmt.Sprintf("The key %q no longer exists in etcd. The provider is configured with on_external_delete = \"error\", so it is not recreated: find out why it was deleted, then either restore it or remove the resource from the state with terraform state rm.", key),
+			}}
+		}
+		logf(ctx, "DEBUG", "key %q no longer exists, removing it from state", key)
+		d.SetId("")
+		return nil
+	}
+
+	// The lease may have expired after the key was read, in which case
+	// etcd is about to delete the key.
+	if lease := clientv3.LeaseID(response.Kvs[0].Lease); lease != clientv3.NoLease {
+		ttl, err := client.TimeToLive(ctx, lease)
+		if err != nil {
+			return classifyEtcdError(err)
+		}
+		if ttl.TTL <= 0 {
+			logf(ctx, "DEBUG", "lease of key %q expired, removing it from state", key)
+			d.SetId("")
+			return nil
+		}
+	}
+
+	logKeyOperation(ctx, "TRACE", "read", key, response.Kvs[0].ModRevision, start, nil)
+
+	if err := d.Set("key", string(response.Kvs[0].Key)); err != nil {
+		return diag.FromErr(err)
+	}
+	// A value read from a file is tracked by its hash only, so a change on
+	// either side shows up as a diff on value_hash.
+	value := decodeValue(d, response.Kvs[0].Value)
+	if d.Get("value_file").(string) != "" {
+		if err := d.Set("value_hash", valueHash(value)); err != nil {
+			return diag.FromErr(err)
+		}
+	} else if err := d.Set(valueAttribute(d), string(value)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("mod_revision", int(response.Kvs[0].ModRevision)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("create_revision", int(response.Kvs[0].CreateRevision)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("version", int(response.Kvs[0].Version)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("lease", formatLeaseID(clientv3.LeaseID(response.Kvs[0].Lease))); err != nil {
+		return diag.FromErr(err)
+	}
+	// A key attached to another lease, or detached, shows up as a diff on
+	// lease_id.
+	if d.Get("lease_id").(string) != "" {
+		if err := d.Set("lease_id", formatLeaseID(clientv3.LeaseID(response.Kvs[0].Lease))); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return nil
 }
 
+func KvResourceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, diags := resourceClient(ctx, d, meta)
+	if diags.HasError() {
+		return diags
+	}
+	ctx = client.startOperation(ctx)
+
+	if d.HasChange("key") {
+		if diags := moveKeyValue(ctx, client, d); diags.HasError() {
+			return diags
+		}
+		return append(diags, KvResourceRead(ctx, d, meta)...)
+	}
+
+	valueChanged := d.HasChanges("value", "sensitive_value", "value_file", "value_hash", "value_template", "rendered_value", "compress")
+	if valueChanged || d.HasChange("lease_id") {
+		key := d.Get("key").(string)
+		value, err := desiredValue(ctx, client, d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		stored, err := encodeValue(d, value)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		logf(ctx, "DEBUG", "updating key %q", key)
+		start := time.Now()
+
+		// Writing in place keeps the key present for watchers, but a key
+		// deleted concurrently must not be silently recreated.
+		var opts []clientv3.OpOption
+		if id := d.Get("lease_id").(string); id != "" {
+			leaseID, err := parseLeaseID(id)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			if d.HasChange("lease_id") {
+				if diags := checkLease(ctx, client, leaseID); diags.HasError() {
+					return diags
+				}
+			}
+			opts = append(opts, clientv3.WithLease(leaseID))
+		}
+		cmp := clientv3util.KeyExists(key)
+		rev, cas := d.GetOk("if_mod_revision")
+		if cas {
+			cmp = clientv3.Compare(clientv3.ModRevision(key), "=", rev.(int))
+		}
+		expected, casValue := d.GetOk("if_value")
+		if casValue {
+			encoded, err := encodeValue(d, expected.(string))
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			cmp = clientv3.Compare(clientv3.Value(key), "=", encoded)
+		}
+		// Moving the key to another lease alone does not need to write the
+		// value, etcd keeps the one it holds.
+		put := clientv3.OpPut(key, stored, opts...)
+		fields := map[string]interface{}{"value": loggedValue(d, value)}
+		keepValue := !valueChanged && d.Get("keep_value_on_lease_change").(bool)
+		if keepValue {
+			put = clientv3.OpPut(key, "", append(opts, clientv3.WithIgnoreValue())...)
+			fields = map[string]interface{}{"lease_id": d.Get("lease_id").(string)}
+		}
+		writeCtx, served := withServedBy(ctx)
+		txn := client.Txn(writeCtx).
+			If(cmp).
+			Then(put)
+		if casValue {
+			txn = txn.Else(clientv3.OpGet(key))
+		}
+		response, err := txn.Commit()
+		if err != nil {
+			return classifyEtcdError(err)
+		}
+		if !response.Succeeded && casValue {
+			return ifValueFailed(d, key, response.Responses[0].GetResponseRange().Kvs)
+		}
+		if !response.Succeeded && cas {
+			return ifModRevisionFailed(key, rev.(int))
+		}
+		if !response.Succeeded {
+			return diag.Errorf("key %q no longer exists in etcd, it was deleted outside of Terraform", key)
+		}
+		logKeyOperation(ctx, "DEBUG", "update", key, response.Header.Revision, start, fields)
+		d.Set("served_by", served.endpoint())
+		if !keepValue {
+			if diags := verifyWrite(ctx, client, d, key, stored, response.Header.Revision); diags.HasError() {
+				return diags
+			}
+		}
+	}
+
+	return append(diags, KvResourceRead(ctx, d, meta)...)
+}
+
 func KvResourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, diags := resourceClient(ctx, d, meta)
+	if diags.HasError() {
+		return diags
+	}
+	ctx = client.startOperation(ctx)
 
 	key := d.Get("key").(string)
+	if diags := checkDeleteProtection(d, fmt.Sprintf("key %q", key)); diags.HasError() {
+		return diags
+	}
 
-	kvc := clientv3.NewKV(client)
-
-	_, err := kvc.Txn(ctx).
+	logf(ctx, "DEBUG", "deleting key %q", key)
+	start := time.Now()
+	logDeleted := d.Get("log_deleted_value").(bool)
+	var opts []clientv3.OpOption
+	if logDeleted {
+		opts = append(opts, clientv3.WithPrevKV())
+	}
+	response, err := client.Txn(ctx).
 		If(clientv3util.KeyExists(key)).
-		Then(clientv3.OpDelete(key)).
+		Then(clientv3.OpDelete(key, opts...)).
 		Commit()
 
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 
 	}
+	level, fields := "DEBUG", map[string]interface{}{
+		"deleted": response.Succeeded,
+	}
+	if logDeleted && response.Succeeded {
+		if prev := response.Responses[0].GetResponseDeleteRange().PrevKvs; len(prev) > 0 {
+			level = "INFO"
+			fields["deleted_value"] = loggedValue(d, string(decodeValue(d, prev[0].Value)))
+			fields["deleted_mod_revision"] = prev[0].ModRevision
+		}
+	}
+	logKeyOperation(ctx, level, "delete", key, response.Header.Revision, start, fields)
+
+	if !response.Succeeded && d.Get("warn_on_missing_delete").(bool) {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Key was already deleted",
+			Detail:   fmt.Sprintf("The key %q no longer existed in etcd when it was destroyed, it may have been removed outside of Terraform.", key),
+		})
+	}
+
+	// Only the lease granted for lease_ttl belongs to the key, an external
+	// lease may hold other keys.
+	if id := d.Get("lease_id").(string); id != "" && d.Get("lease_ttl").(int) > 0 {
+		leaseID, err := parseLeaseID(id)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if _, err := client.Revoke(ctx, leaseID); err != nil && err != rpctypes.ErrLeaseNotFound {
+			return classifyEtcdError(err)
+		}
+	}
 	d.SetId("")
+	return diags
+}
+
+// moveKeyValue writes the value to the new key and deletes the old one in a
+// single transaction, so the value is never missing from both. The other
+// changes of the update are applied to the new key in the same write.
+func moveKeyValue(ctx context.Context, client *apiClient, d *schema.ResourceData) diag.Diagnostics {
+	old, new := d.GetChange("key")
+	from, to := old.(string), new.(string)
+
+	value, err := desiredValue(ctx, client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	stored, err := encodeValue(d, value)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	response, err := client.Get(ctx, from)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if len(response.Kvs) == 0 {
+		return diag.Errorf("unable to move key %q to %q, it no longer exists in etcd, it was deleted outside of Terraform", from, to)
+	}
+	current := response.Kvs[0]
+
+	// The key keeps its lease unless it is attached to another one.
+	lease := clientv3.LeaseID(current.Lease)
+	if d.HasChange("lease_id") {
+		if lease, err = parseLeaseID(d.Get("lease_id").(string)); err != nil {
+			return diag.FromErr(err)
+		}
+		if diags := checkLease(ctx, client, lease); diags.HasError() {
+			return diags
+		}
+	}
+	var opts []clientv3.OpOption
+	if lease != clientv3.NoLease {
+		opts = append(opts, clientv3.WithLease(lease))
+	}
+
+	// if_mod_revision and if_value guard the key being moved as they guard
+	// an update in place.
+	cmps := []clientv3.Cmp{
+		clientv3.Compare(clientv3.ModRevision(from), "=", current.ModRevision),
+		clientv3util.KeyMissing(to),
+	}
+	rev, cas := d.GetOk("if_mod_revision")
+	if cas {
+		cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(from), "=", rev.(int)))
+	}
+	expected, casValue := d.GetOk("if_value")
+	var encoded string
+	if casValue {
+		if encoded, err = encodeValue(d, expected.(string)); err != nil {
+			return diag.FromErr(err)
+		}
+		cmps = append(cmps, clientv3.Compare(clientv3.Value(from), "=", encoded))
+	}
+
+	logf(ctx, "DEBUG", "moving key %q to %q", from, to)
+	start := time.Now()
+	writeCtx, served := withServedBy(ctx)
+	txn, err := client.Txn(writeCtx).
+		If(cmps...).
+		Then(
+			clientv3.OpPut(to, stored, opts...),
+			clientv3.OpDelete(from),
+		).
+		Else(clientv3.OpGet(from)).
+		Commit()
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if !txn.Succeeded {
+		kvs := txn.Responses[0].GetResponseRange().Kvs
+		if casValue && (len(kvs) == 0 || string(kvs[0].Value) != encoded) {
+			return ifValueFailed(d, from, kvs)
+		}
+		if cas && (len(kvs) == 0 || kvs[0].ModRevision != int64(rev.(int))) {
+			return ifModRevisionFailed(from, rev.(int))
+		}
+		existing, err := client.Get(ctx, to, clientv3.WithCountOnly())
+		if err == nil && existing.Count > 0 {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "Key already exists",
+				Detail:   fmt.Sprintf("The key %q could not be moved to %q, which already exists in etcd. Both keys were left unchanged.", from, to),
+			}}
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Key was modified concurrently",
+			Detail:   fmt.Sprintf("The key %q was written or deleted while it was being moved to %q, nothing was moved. Apply again to move its current value.", from, to),
+		}}
+	}
+	logKeyOperation(ctx, "DEBUG", "move", to, txn.Header.Revision, start, map[string]interface{}{
+		"from":  from,
+		"value": loggedValue(d, value),
+	})
+	d.SetId(to)
+	d.Set("served_by", served.endpoint())
+	return verifyWrite(ctx, client, d, to, stored, txn.Header.Revision)
+}
+
+// ifValueFailed reports that key was not written because it no longer holds
+// if_value, kvs being what it holds instead.
+func ifValueFailed(d *schema.ResourceData, key string, kvs []*mvccpb.KeyValue) diag.Diagnostics {
+	actual := "the key no longer exists"
+	if len(kvs) > 0 {
+		actual = fmt.Sprintf("it holds %q", loggedValue(d, string(decodeValue(d, kvs[0].Value))))
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Key does not hold the expected value",
+		Detail:   fmt.Sprintf("The key %q was not updated because it no longer holds the value of if_value, %s. Refresh to read the current value.", key, actual),
+	}}
+}
+
+// ifModRevisionFailed reports that key was not written because it is no
+// longer at if_mod_revision.
+func ifModRevisionFailed(key string, revision int) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Key was modified concurrently",
+		Detail:   fmt.Sprintf("The key %q is no longer at mod revision %d, it was written or deleted by someone else. Refresh to read the current value and revision.", key, revision),
+	}}
+}
+
+// desiredValue returns the value to write, read from value_file or rendered
+// from value_template when set.
+func desiredValue(ctx context.Context, client *apiClient, d *schema.ResourceData) (string, error) {
+	if text := d.Get("value_template").(string); text != "" {
+		return renderValueTemplate(ctx, client, text)
+	}
+	path := d.Get("value_file").(string)
+	if path == "" {
+		return d.Get(valueAttribute(d)).(string), nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read value_file: %v", err)
+	}
+	return string(content), nil
+}
+
+// defaultMaxValueBytes is the default --max-request-bytes of etcd, the
+// largest request a member accepts unless it is configured otherwise.
+const defaultMaxValueBytes = 1536 * 1024
+
+// encodeValue returns value as it is stored in etcd, gzip compressed when
+// compress is set.
+func encodeValue(d interface{ Get(string) interface{} }, value string) (string, error) {
+	if !d.Get("compress").(bool) {
+		return value, nil
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// decodeValue returns the value of a key as it was written by encodeValue.
+// The gzip header marks a compressed value, anything else, such as a value
+// written before compress was turned on, is returned as is and shows up as a
+// diff.
+func decodeValue(d *schema.ResourceData, stored []byte) []byte {
+	if !d.Get("compress").(bool) {
+		return stored
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return stored
+	}
+	value, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return stored
+	}
+	return value
+}
+
+// checkValueSize rejects values larger than max once stored, after
+// compression when it is enabled.
+func checkValueSize(d *schema.ResourceDiff, value string, max int) error {
+	stored, err := encodeValue(d, value)
+	if err != nil {
+		return err
+	}
+	if len(stored) <= max {
+		return nil
+	}
+	if d.Get("compress").(bool) {
+		return fmt.Errorf("value of key %q is %d bytes after compression, more than the max_value_bytes of %d accepted by etcd", d.Get("key").(string), len(stored), max)
+	}
+	return fmt.Errorf("value of key %q is %d bytes, more than the max_value_bytes of %d accepted by etcd, consider setting compress", d.Get("key").(string), len(stored), max)
+}
+
+// valueAttribute returns the attribute the inline value of the key is set
+// in, sensitive_value, rendered_value or value.
+func valueAttribute(d interface{ Get(string) interface{} }) string {
+	if d.Get("value_template").(string) != "" {
+		return "rendered_value"
+	}
+	if d.Get("sensitive_value").(string) != "" {
+		return "sensitive_value"
+	}
+	return "value"
+}
+
+// loggedValue returns value as it may appear in the logs: redacted when the
+// key is sensitive, and as its hash when it is read from value_file.
+func loggedValue(d *schema.ResourceData, value string) string {
+	switch {
+	case d.Get("sensitive").(bool), d.Get("sensitive_value").(string) != "":
+		return "(sensitive)"
+	case d.Get("value_file").(string) != "":
+		return "sha256:" + valueHash([]byte(value))
+	}
+	return value
+}
+
+func valueHash(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkLease fails unless the lease exists and has not expired.
+func checkLease(ctx context.Context, client *apiClient, leaseID clientv3.LeaseID) diag.Diagnostics {
+	// Lease 0 detaches the key, there is nothing to check.
+	if leaseID == clientv3.NoLease {
+		return nil
+	}
+	ttl, err := client.TimeToLive(ctx, leaseID)
+	if err != nil && err != rpctypes.ErrLeaseNotFound {
+		return classifyEtcdError(err)
+	}
+	if err != nil || ttl.TTL <= 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Lease not found",
+			Detail:   fmt.Sprintf("The lease %s does not exist or has expired, the key cannot be attached to it.", formatLeaseID(leaseID)),
+		}}
+	}
 	return nil
 }
+
+func validateLeaseID(value interface{}, key string) ([]string, []error) {
+	if _, err := parseLeaseID(value.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%s: %v", key, err)}
+	}
+	return nil, nil
+}
+
+// verifyWrite reads key back at the revision it was written at when
+// verify_write is set, and fails unless etcd holds the stored value, to
+// catch writes lost or altered on the way to the cluster. Reading at the
+// revision of the write keeps later writes of other clients out of the
+// comparison.
+func verifyWrite(ctx context.Context, client *apiClient, d *schema.ResourceData, key string, stored string, revision int64) diag.Diagnostics {
+	if !d.Get("verify_write").(bool) {
+		return nil
+	}
+	response, err := client.Get(ctx, key, clientv3.WithRev(revision))
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to read key %q back to verify the write: %w", key, err))
+	}
+	if len(response.Kvs) > 0 && bytes.Equal(response.Kvs[0].Value, []byte(stored)) {
+		logf(ctx, "DEBUG", "verified the write of key %q at revision %d", key, revision)
+		return nil
+	}
+	actual := "the key does not exist"
+	if len(response.Kvs) > 0 {
+		actual = fmt.Sprintf("it holds %q", loggedValue(d, string(decodeValue(d, response.Kvs[0].Value))))
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Write verification failed",
+		Detail:   fmt.Sprintf("The key %q was written at revision %d, but reading it back %s instead of the value written. The write may have been dropped or altered on the way to the cluster, check any proxy in between before applying again.", key, revision, actual),
+	}}
+}
+
+// leaseExpired reports whether the lease the key was attached to when last
+// read no longer exists, in which case etcd deleted the key itself.
+func leaseExpired(ctx context.Context, client *apiClient, d *schema.ResourceData) bool {
+	lease, err := parseLeaseID(d.Get("lease").(string))
+	if err != nil || lease == clientv3.NoLease {
+		return false
+	}
+	ttl, err := client.TimeToLive(ctx, lease)
+	return err == nil && ttl.TTL <= 0
+}
+
+func formatLeaseID(id clientv3.LeaseID) string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+func parseLeaseID(id string) (clientv3.LeaseID, error) {
+	leaseID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("invalid lease ID %q: %v", id, err)
+	}
+	return clientv3.LeaseID(leaseID), nil
+}
@@ -1 +1,1196 @@
 package etcd
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKvResourceDeleteWarnOnMissing(test *testing.T) {
+	kv := newFakeKV()
+
+	d := schema.TestResourceDataRaw(test, KvResource().Schema, map[string]interface{}{
+		"key":                    "/app/gone",
+		"value":                  "value",
+		"warn_on_missing_delete": true,
+	})
+	d.SetId("/app/gone")
+
+	diags := KvResourceDelete(context.Background(), d, newFakeClient(kv))
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		test.Fatalf("expected a single warning, got %v", diags)
+	}
+	if d.Id() != "" {
+		test.Fatalf("expected the id to be cleared, got %q", d.Id())
+	}
+}
+
+func TestKvResourceDeleteMissingWithoutWarning(test *testing.T) {
+	kv := newFakeKV()
+
+	d := schema.TestResourceDataRaw(test, KvResource().Schema, map[string]interface{}{
+		"key":   "/app/gone",
+		"value": "value",
+	})
+	d.SetId("/app/gone")
+
+	if diags := KvResourceDelete(context.Background(), d, newFakeClient(kv)); len(diags) != 0 {
+		test.Fatalf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestKvResourceReadPopulatesValue(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/name", "changed")
+
+	d := schema.TestResourceDataRaw(test, KvResource().Schema, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "passbase",
+	})
+	d.SetId("/app/name")
+
+	if diags := KvResourceRead(context.Background(), d, newFakeClient(kv)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got := d.Get("key").(string); got != "/app/name" {
+		test.Fatalf("key: expected %q, got %q", "/app/name", got)
+	}
+	if got := d.Get("value").(string); got != "changed" {
+		test.Fatalf("value: expected %q, got %q", "changed", got)
+	}
+	if d.Id() != "/app/name" {
+		test.Fatalf("expected the id to be kept, got %q", d.Id())
+	}
+}
+
+func TestKvResourceReadMissingKey(test *testing.T) {
+	kv := newFakeKV()
+
+	d := schema.TestResourceDataRaw(test, KvResource().Schema, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "passbase",
+	})
+	d.SetId("/app/name")
+
+	if diags := KvResourceRead(context.Background(), d, newFakeClient(kv)); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if d.Id() != "" {
+		test.Fatalf("expected the id to be cleared, got %q", d.Id())
+	}
+}
+
+func TestKvResourceUpdateValueInPlace(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/app/name", "passbase")
+
+	state := &terraform.InstanceState{
+		ID:         "/app/name",
+		Attributes: map[string]string{"id": "/app/name", "key": "/app/name", "value": "passbase"},
+	}
+	state, diags := applyTestResource(test, KvResource(), state, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "awesome",
+	}, newFakeClient(kv))
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/name", "awesome")
+
+	response, _ := kv.Get(ctx, "/app/name")
+	if response.Kvs[0].Version != 2 || response.Kvs[0].CreateRevision != 2 {
+		test.Fatalf("expected the key to be updated in place, got %v", response.Kvs[0])
+	}
+	if state.ID != "/app/name" || state.Attributes["value"] != "awesome" {
+		test.Fatalf("unexpected state %v", state)
+	}
+}
+
+func TestKvResourceUpdateDeletedKey(test *testing.T) {
+	kv := newFakeKV()
+
+	state := &terraform.InstanceState{
+		ID:         "/app/name",
+		Attributes: map[string]string{"id": "/app/name", "key": "/app/name", "value": "passbase"},
+	}
+	_, diags := applyTestResource(test, KvResource(), state, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "awesome",
+	}, newFakeClient(kv))
+	if !diags.HasError() {
+		test.Fatalf("expected an error updating a deleted key")
+	}
+	if response, _ := kv.Get(context.Background(), "/app/name"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the deleted key not to be recreated")
+	}
+}
+
+func TestKvResourceEmptyValueStaysManaged(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	config := map[string]interface{}{
+		"key":   "/app/flag",
+		"value": "",
+	}
+
+	state, diags := applyTestResource(test, KvResource(), nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/flag", "")
+
+	state, diags = KvResource().RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state == nil || state.ID != "/app/flag" {
+		test.Fatalf("expected the empty key to stay managed, got %v", state)
+	}
+
+	diff, err := KvResource().Diff(ctx, state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if !diff.Empty() {
+		test.Fatalf("expected no changes for an empty value, got %v", diff)
+	}
+
+	kv.Delete(ctx, "/app/flag")
+	state, diags = KvResource().RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state != nil {
+		test.Fatalf("expected a deleted key to be removed from state, got %v", state)
+	}
+}
+
+func TestKvResourceImport(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/my/key", "existing")
+
+	r := KvResource()
+	d := r.Data(&terraform.InstanceState{ID: "/my/key"})
+	imported, err := r.Importer.StateContext(ctx, d, client)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if len(imported) != 1 {
+		test.Fatalf("expected a single imported resource, got %d", len(imported))
+	}
+
+	state, diags := r.RefreshWithoutUpgrade(ctx, imported[0].State(), client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.ID != "/my/key" || state.Attributes["key"] != "/my/key" || state.Attributes["value"] != "existing" {
+		test.Fatalf("unexpected imported state %v", state)
+	}
+}
+
+func TestKvResourceLeaseTTL(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+
+	state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":       "/locks/worker",
+		"value":     "held",
+		"lease_ttl": 30,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	leaseID, err := parseLeaseID(state.Attributes["lease_id"])
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	response, _ := kv.Get(ctx, "/locks/worker")
+	if len(response.Kvs) != 1 || clientv3.LeaseID(response.Kvs[0].Lease) != leaseID {
+		test.Fatalf("expected the key to be attached to lease %d, got %v", leaseID, response.Kvs)
+	}
+
+	state, diags = KvResource().RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() || state == nil || state.Attributes["lease_id"] != formatLeaseID(leaseID) {
+		test.Fatalf("expected the lease to survive a refresh, got %v %v", state, diags)
+	}
+
+	if _, diags = applyTestResource(test, KvResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if ttl, _ := lease.TimeToLive(ctx, leaseID); ttl.TTL != -1 {
+		test.Fatalf("expected the lease to be revoked on delete")
+	}
+}
+
+func TestKvResourceReadExpiredLease(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+
+	state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":       "/locks/worker",
+		"value":     "held",
+		"lease_ttl": 30,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	leaseID, _ := parseLeaseID(state.Attributes["lease_id"])
+	lease.expire(leaseID)
+
+	state, diags = KvResource().RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state != nil {
+		test.Fatalf("expected a key with an expired lease to be removed from state, got %v", state)
+	}
+}
+
+func TestKvResourceOnExternalDelete(test *testing.T) {
+	ctx := context.Background()
+
+	for _, onExternalDelete := range []string{"recreate", "error"} {
+		test.Run(onExternalDelete, func(test *testing.T) {
+			kv := newFakeKV()
+			client := newFakeClient(kv)
+			client.onExternalDelete = onExternalDelete
+
+			state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+				"key":   "/app/name",
+				"value": "passbase",
+			}, client)
+			if diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+
+			kv.Delete(ctx, "/app/name")
+			refreshed, diags := KvResource().RefreshWithoutUpgrade(ctx, state, client)
+			if onExternalDelete == "recreate" {
+				if diags.HasError() || refreshed != nil {
+					test.Fatalf("expected the deleted key to be removed from state, got %v (%v)", refreshed, diags)
+				}
+				return
+			}
+			if !diags.HasError() || diags[0].Summary != "Key deleted outside of Terraform" {
+				test.Fatalf("expected the deletion to be reported, got %v", diags)
+			}
+			if response, _ := kv.Get(ctx, "/app/name"); len(response.Kvs) != 0 {
+				test.Fatalf("expected the key not to be recreated")
+			}
+		})
+	}
+
+	// A key deleted by etcd because its lease expired is still recreated.
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	client.onExternalDelete = "error"
+	state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":       "/locks/worker",
+		"value":     "held",
+		"lease_ttl": 30,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	leaseID, _ := parseLeaseID(state.Attributes["lease_id"])
+	lease.expire(leaseID)
+	if state, diags = KvResource().RefreshWithoutUpgrade(ctx, state, client); diags.HasError() || state != nil {
+		test.Fatalf("expected a key with an expired lease to be removed from state, got %v (%v)", state, diags)
+	}
+}
+
+func TestKvResourceCompareAndSwap(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	r := KvResource()
+
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "v1",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["mod_revision"] != "2" {
+		test.Fatalf("expected mod_revision 2 after create, got %v", state.Attributes)
+	}
+
+	state, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":             "/app/name",
+		"value":           "v2",
+		"if_mod_revision": 2,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/name", "v2")
+	if state.Attributes["mod_revision"] != "3" {
+		test.Fatalf("expected mod_revision 3 after update, got %v", state.Attributes)
+	}
+
+	// Someone else writes the key before the next apply.
+	kv.Put(ctx, "/app/name", "theirs")
+	_, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":             "/app/name",
+		"value":           "v3",
+		"if_mod_revision": 3,
+	}, client)
+	if !diags.HasError() || diags[0].Summary != "Key was modified concurrently" {
+		test.Fatalf("expected a concurrent modification error, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/name", "theirs")
+}
+
+func TestKvResourceMetadata(test *testing.T) {
+	kv := newFakeKV()
+	client := newFakeLeaseClient(kv, newFakeLease(kv))
+	defer client.keepAlives.close()
+	r := KvResource()
+
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":       "/app/name",
+		"value":     "v1",
+		"lease_ttl": 60,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	for attribute, expected := range map[string]string{
+		"create_revision": "2",
+		"mod_revision":    "2",
+		"version":         "1",
+		"lease":           state.Attributes["lease_id"],
+	} {
+		if got := state.Attributes[attribute]; got != expected {
+			test.Fatalf("%s after create: expected %q, got %q", attribute, expected, got)
+		}
+	}
+
+	state, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":       "/app/name",
+		"value":     "v2",
+		"lease_ttl": 60,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	for attribute, expected := range map[string]string{
+		"create_revision": "2",
+		"mod_revision":    "3",
+		"version":         "2",
+	} {
+		if got := state.Attributes[attribute]; got != expected {
+			test.Fatalf("%s after update: expected %q, got %q", attribute, expected, got)
+		}
+	}
+}
+
+func TestKvResourceJSONValueFormat(test *testing.T) {
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	r := KvResource()
+
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":          "/app/config",
+		"value":        `{"name": "passbase", "replicas": 3}`,
+		"value_format": "json",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", `{"name": "passbase", "replicas": 3}`)
+
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":          "/app/config",
+		"value":        "{\n  \"replicas\": 3,\n  \"name\": \"passbase\"\n}",
+		"value_format": "json",
+	}), client)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if diff != nil && !diff.Empty() {
+		test.Fatalf("expected reformatting not to cause a diff, got %v", diff)
+	}
+
+	diff, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":          "/app/config",
+		"value":        `{"name": "passbase", "replicas": 4}`,
+		"value_format": "json",
+	}), client)
+	if err != nil || diff == nil || diff.Attributes["value"] == nil {
+		test.Fatalf("expected a semantic change to cause a diff, got %v (%v)", diff, err)
+	}
+
+	_, err = r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":          "/app/other",
+		"value":        `{"name": `,
+		"value_format": "json",
+	}), client)
+	if err == nil {
+		test.Fatalf("expected invalid JSON to fail the plan")
+	}
+
+	// Moving the key writes it again, even when its document is unchanged.
+	diff, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":          "/app/settings",
+		"value":        `{"name": "passbase", "replicas": 3}`,
+		"value_format": "json",
+	}), client)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	for _, attribute := range []string{"create_revision", "mod_revision", "version", "served_by"} {
+		if diff.Attributes[attribute] == nil || !diff.Attributes[attribute].NewComputed {
+			test.Fatalf("expected %s to be unknown until the key is moved, got %#v", attribute, diff.Attributes[attribute])
+		}
+	}
+}
+
+func TestKvResourceCreateErrors(test *testing.T) {
+	for _, tc := range []struct {
+		err     error
+		summary string
+	}{
+		{rpctypes.ErrPermissionDenied, "Permission denied"},
+		{rpctypes.ErrGRPCPermissionDenied, "Permission denied"},
+		{rpctypes.ErrAuthNotEnabled, "Authentication is not enabled"},
+		{rpctypes.ErrLeaseNotFound, "Lease not found"},
+		{status.Error(codes.Unavailable, "connection refused"), "etcd cluster unavailable"},
+		{rpctypes.ErrNoLeader, "etcd leader election in progress"},
+		{context.Canceled, "Operation was cancelled"},
+		{context.DeadlineExceeded, "Operation timed out"},
+		{errors.New("boom"), "boom"},
+	} {
+		kv := newFakeKV()
+		kv.errs = []error{tc.err}
+
+		d := schema.TestResourceDataRaw(test, KvResource().Schema, map[string]interface{}{
+			"key":   "/app/name",
+			"value": "passbase",
+		})
+		diags := KvResourceCreate(context.Background(), d, newFakeClient(kv))
+		if !diags.HasError() || diags[0].Summary != tc.summary {
+			test.Fatalf("%v: expected %q, got %v", tc.err, tc.summary, diags)
+		}
+		if d.Id() != "" {
+			test.Fatalf("%v: expected no id, got %q", tc.err, d.Id())
+		}
+	}
+}
+
+func TestKvResourceCreateExistingKey(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/name", "existing")
+
+	state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "passbase",
+	}, newFakeClient(kv))
+	if !diags.HasError() || diags[0].Summary != "Key already exists" {
+		test.Fatalf("expected the create to fail on the existing key, got %v", diags)
+	}
+	if state != nil && state.ID != "" {
+		test.Fatalf("expected the existing key not to be adopted, got %v", state)
+	}
+	assertKeyValue(test, kv, "/app/name", "existing")
+}
+
+func TestKvResourceCreateOverwrite(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/name", "existing")
+
+	state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":       "/app/name",
+		"value":     "passbase",
+		"overwrite": true,
+	}, newFakeClient(kv))
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.ID != "/app/name" || state.Attributes["value"] != "passbase" || state.Attributes["version"] != "2" {
+		test.Fatalf("expected the overwritten key in state, got %v", state.Attributes)
+	}
+	assertKeyValue(test, kv, "/app/name", "passbase")
+}
+
+func TestKvResourceValueFile(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	path := filepath.Join(test.TempDir(), "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"replicas": 3}`), 0600); err != nil {
+		test.Fatalf("err: %s", err)
+	}
+
+	r := KvResource()
+	config := map[string]interface{}{
+		"key":        "/app/config",
+		"value_file": path,
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", `{"replicas": 3}`)
+	if state.Attributes["value_hash"] != valueHash([]byte(`{"replicas": 3}`)) {
+		test.Fatalf("expected the hash of the file in state, got %v", state.Attributes)
+	}
+
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil || diff != nil && !diff.Empty() {
+		test.Fatalf("expected no diff for an unchanged file, got %v (%v)", diff, err)
+	}
+
+	// A change of the file updates the key.
+	if err := ioutil.WriteFile(path, []byte(`{"replicas": 5}`), 0600); err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", `{"replicas": 5}`)
+
+	// So does a change of the key outside of Terraform.
+	kv.Put(ctx, "/app/config", "drifted")
+	if state, diags = r.RefreshWithoutUpgrade(ctx, state, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if _, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config", `{"replicas": 5}`)
+}
+
+func TestKvResourceValueFileValidation(test *testing.T) {
+	r := KvResource()
+	ctx := context.Background()
+
+	_, err := r.Diff(ctx, nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":        "/app/config",
+		"value_file": filepath.Join(test.TempDir(), "missing.json"),
+	}), newFakeClient(newFakeKV()))
+	if err == nil || !strings.Contains(err.Error(), "unable to read value_file") {
+		test.Fatalf("expected a missing file to fail the plan, got %v", err)
+	}
+
+	diags := r.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":        "/app/config",
+		"value":      "inline",
+		"value_file": "config.json",
+	}))
+	if !diags.HasError() {
+		test.Fatalf("expected value and value_file to be mutually exclusive")
+	}
+}
+
+func TestKvResourceKeepValueOnLeaseChange(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	first, _ := lease.Grant(ctx, 60)
+	second, _ := lease.Grant(ctx, 60)
+
+	r := KvResource()
+	config := map[string]interface{}{
+		"key":                        "/workers/a",
+		"value":                      "up",
+		"lease_id":                   formatLeaseID(first.ID),
+		"keep_value_on_lease_change": true,
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	// The worker updated its value since the last refresh, moving the key to
+	// the second lease must not overwrite it.
+	kv.Put(ctx, "/workers/a", "busy", clientv3.WithLease(first.ID))
+
+	config["lease_id"] = formatLeaseID(second.ID)
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/workers/a", "busy")
+	if keys := lease.attachedKeys(second.ID); len(keys) != 1 || string(keys[0]) != "/workers/a" {
+		test.Fatalf("expected the key to be attached to the second lease, got %q", keys)
+	}
+	if state.Attributes["value"] != "busy" || state.Attributes["lease"] != formatLeaseID(second.ID) {
+		test.Fatalf("expected the kept value and the new lease to be read back, got %v", state.Attributes)
+	}
+}
+
+func TestKvResourceExternalLease(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	shared, _ := lease.Grant(ctx, 60)
+	other, _ := lease.Grant(ctx, 60)
+
+	r := KvResource()
+	config := map[string]interface{}{
+		"key":      "/workers/a",
+		"value":    "up",
+		"lease_id": formatLeaseID(shared.ID),
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if keys := lease.attachedKeys(shared.ID); len(keys) != 1 || string(keys[0]) != "/workers/a" {
+		test.Fatalf("expected the key to be attached to the shared lease, got %q", keys)
+	}
+
+	// Attaching the key to another lease outside of Terraform is drift.
+	kv.Put(ctx, "/workers/a", "up", clientv3.WithLease(other.ID))
+	if state, diags = r.RefreshWithoutUpgrade(ctx, state, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["lease_id"] != formatLeaseID(other.ID) {
+		test.Fatalf("expected the lease drift to be read back, got %v", state.Attributes)
+	}
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if keys := lease.attachedKeys(shared.ID); len(keys) != 1 {
+		test.Fatalf("expected the key to be attached to the shared lease again, got %q", keys)
+	}
+
+	// Destroying the key leaves the shared lease alone.
+	if _, diags = applyTestResource(test, r, state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if ttl, _ := lease.TimeToLive(ctx, shared.ID); ttl.TTL <= 0 {
+		test.Fatalf("expected the shared lease not to be revoked")
+	}
+}
+
+func TestKvResourceDetachExternalLease(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	shared, _ := lease.Grant(ctx, 60)
+
+	r := KvResource()
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":      "/workers/a",
+		"value":    "up",
+		"lease_id": formatLeaseID(shared.ID),
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	// lease_id also holds the lease of lease_ttl, so removing it from the
+	// configuration plans no change.
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":   "/workers/a",
+		"value": "up",
+	}), client)
+	if err != nil || (diff != nil && !diff.Empty()) {
+		test.Fatalf("expected no change without lease_id, got %v (%v)", diff, err)
+	}
+
+	config := map[string]interface{}{
+		"key":      "/workers/a",
+		"value":    "up",
+		"lease_id": "0",
+	}
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if keys := lease.attachedKeys(shared.ID); len(keys) != 0 {
+		test.Fatalf("expected the key to be detached from the lease, got %q", keys)
+	}
+	assertKeyValue(test, kv, "/workers/a", "up")
+	if diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), client); err != nil || (diff != nil && !diff.Empty()) {
+		test.Fatalf("expected the detached key to plan no change, got %v (%v)", diff, err)
+	}
+}
+
+func TestKvResourceExternalLeaseNotFound(test *testing.T) {
+	kv := newFakeKV()
+	_, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":      "/workers/a",
+		"value":    "up",
+		"lease_id": "12345",
+	}, newFakeLeaseClient(kv, newFakeLease(kv)))
+	if !diags.HasError() || diags[0].Summary != "Lease not found" {
+		test.Fatalf("expected a missing lease error, got %v", diags)
+	}
+	if response, _ := kv.Get(context.Background(), "/workers/a"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the key not to be written")
+	}
+}
+
+func TestKvResourceSensitiveValue(test *testing.T) {
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	r := KvResource()
+
+	diff, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":             "/app/password",
+		"sensitive_value": "hunter2",
+	}), client)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	for name, attribute := range diff.Attributes {
+		if strings.Contains(attribute.New, "hunter2") && !attribute.Sensitive {
+			test.Fatalf("expected %s to be redacted from the plan, got %v", name, attribute)
+		}
+	}
+	if attribute := diff.Attributes["sensitive_value"]; attribute == nil || !attribute.Sensitive {
+		test.Fatalf("expected sensitive_value to be marked sensitive, got %v", attribute)
+	}
+
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":             "/app/password",
+		"sensitive_value": "hunter2",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/password", "hunter2")
+
+	kv.Put(context.Background(), "/app/password", "changed")
+	state, diags = r.RefreshWithoutUpgrade(context.Background(), state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["sensitive_value"] != "changed" || state.Attributes["value"] != "" {
+		test.Fatalf("expected the value to be read back into sensitive_value, got %v", state.Attributes)
+	}
+}
+
+func TestKvResourceCompress(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	r := KvResource()
+	value := strings.Repeat("passbase ", 1024)
+
+	config := map[string]interface{}{
+		"key":      "/app/blob",
+		"value":    value,
+		"compress": true,
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	response, _ := kv.Get(ctx, "/app/blob")
+	if stored := response.Kvs[0].Value; len(stored) >= len(value) || stored[0] != 0x1f || stored[1] != 0x8b {
+		test.Fatalf("expected a gzip compressed value, got %d bytes", len(stored))
+	}
+
+	state, diags = r.RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["value"] != value {
+		test.Fatalf("expected the uncompressed value in state")
+	}
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if !diff.Empty() {
+		test.Fatalf("expected no changes, got %v", diff)
+	}
+
+	config["compress"] = false
+	if _, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/blob", value)
+}
+
+func TestKvResourceMaxValueBytes(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.maxValueBytes = 1024
+
+	random := make([]byte, 1024)
+	rand.Read(random)
+	for _, tc := range []struct {
+		name   string
+		config map[string]interface{}
+		fails  bool
+	}{
+		{"just under", map[string]interface{}{"value": strings.Repeat("a", 1024)}, false},
+		{"just over", map[string]interface{}{"value": strings.Repeat("a", 1025)}, true},
+		{"compressed under", map[string]interface{}{"value": strings.Repeat("a", 4096), "compress": true}, false},
+		{"compressed over", map[string]interface{}{"value": string(random), "compress": true}, true},
+		{"sensitive over", map[string]interface{}{"sensitive_value": strings.Repeat("a", 1025)}, true},
+	} {
+		tc.config["key"] = "/app/blob"
+		_, err := KvResource().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(tc.config), client)
+		if (err != nil) != tc.fails {
+			test.Fatalf("%s: expected failure %v, got %v", tc.name, tc.fails, err)
+		}
+	}
+}
+
+func TestKvResourceMaxValueBytesFile(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.maxValueBytes = 1024
+	path := filepath.Join(test.TempDir(), "value")
+	ioutil.WriteFile(path, []byte(strings.Repeat("a", 1025)), 0600)
+
+	_, err := KvResource().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":        "/app/blob",
+		"value_file": path,
+	}), client)
+	if err == nil || !strings.Contains(err.Error(), "1025 bytes") {
+		test.Fatalf("expected the file content to be checked, got %v", err)
+	}
+}
+
+func TestKvResourceDeleteCancelled(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/app/name", "passbase")
+	client := newFakeClient(kv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := schema.TestResourceDataRaw(test, KvResource().Schema, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "passbase",
+	})
+	d.SetId("/app/name")
+	kv.errs = []error{ctx.Err()}
+
+	diags := KvResourceDelete(ctx, d, client)
+	if !diags.HasError() || diags[0].Summary != "Operation was cancelled" {
+		test.Fatalf("expected the cancellation to be reported, got %v", diags)
+	}
+	if d.Id() == "" {
+		test.Fatalf("expected the key to stay in state")
+	}
+}
+
+func TestKvResourceCreateOnlyWithLease(test *testing.T) {
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	contender := func(value string) map[string]interface{} {
+		return map[string]interface{}{
+			"key":                    "/election/leader",
+			"value":                  value,
+			"lease_ttl":              10,
+			"create_only_with_lease": true,
+		}
+	}
+
+	first, diags := applyTestResource(test, KvResource(), nil, contender("node-a"), client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	_, diags = applyTestResource(test, KvResource(), nil, contender("node-b"), client)
+	if !diags.HasError() || diags[0].Summary != "Key already held" || !strings.Contains(diags[0].Detail, first.Attributes["lease_id"]) {
+		test.Fatalf("expected the second contender to lose to lease %s, got %v", first.Attributes["lease_id"], diags)
+	}
+	assertKeyValue(test, kv, "/election/leader", "node-a")
+
+	leaseID, _ := parseLeaseID(first.Attributes["lease_id"])
+	lease.expire(leaseID)
+	if _, diags = applyTestResource(test, KvResource(), nil, contender("node-b"), client); diags.HasError() {
+		test.Fatalf("expected the second contender to win once the lease expired, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/election/leader", "node-b")
+}
+
+func TestKvResourceCreateOnlyWithLeaseRequiresLease(test *testing.T) {
+	kv := newFakeKV()
+	_, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":                    "/election/leader",
+		"value":                  "node-a",
+		"create_only_with_lease": true,
+	}, newFakeClient(kv))
+	if !diags.HasError() || kv.txns != 0 {
+		test.Fatalf("expected create_only_with_lease without a lease to fail before writing, got %v", diags)
+	}
+}
+
+func TestKvResourceUpdateIfValue(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	r := KvResource()
+
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":   "/app/replicas",
+		"value": "3",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":      "/app/replicas",
+		"value":    "4",
+		"if_value": "3",
+	}, client); diags.HasError() {
+		test.Fatalf("expected the update from the expected value to succeed, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/replicas", "4")
+
+	kv.Put(ctx, "/app/replicas", "7")
+	_, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":      "/app/replicas",
+		"value":    "5",
+		"if_value": "4",
+	}, client)
+	if !diags.HasError() || diags[0].Summary != "Key does not hold the expected value" || !strings.Contains(diags[0].Detail, `"7"`) {
+		test.Fatalf("expected the update to be refused with the actual value, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/replicas", "7")
+}
+
+func TestKvResourceValueTemplate(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/app/db/host", "db.internal")
+	kv.Put(ctx, "/app/db/port", "5432")
+
+	r := KvResource()
+	config := map[string]interface{}{
+		"key":            "/app/db/url",
+		"value_template": `postgres://{{ key "/app/db/host" }}:{{ key "/app/db/port" }}/app`,
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/db/url", "postgres://db.internal:5432/app")
+	if state.Attributes["rendered_value"] != "postgres://db.internal:5432/app" {
+		test.Fatalf("expected the rendered value in state, got %v", state.Attributes)
+	}
+
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil || diff != nil && !diff.Empty() {
+		test.Fatalf("expected no diff while the referenced keys are unchanged, got %v (%v)", diff, err)
+	}
+
+	// A change of a referenced key renders the value again.
+	kv.Put(ctx, "/app/db/port", "6432")
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/db/url", "postgres://db.internal:6432/app")
+
+	// A referenced key that no longer exists fails the apply.
+	kv.Delete(ctx, "/app/db/host")
+	_, diags = applyTestResource(test, r, state, config, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, `key "/app/db/host" does not exist`) {
+		test.Fatalf("expected the missing key to be reported, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/db/url", "postgres://db.internal:6432/app")
+}
+
+func TestKvResourceValueTemplateValidation(test *testing.T) {
+	diags := KvResource().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":            "/app/db/url",
+		"value_template": `{{ key "/app/db/host" }`,
+	}))
+	if !diags.HasError() {
+		test.Fatalf("expected an invalid template to fail the plan")
+	}
+}
+
+func TestKvResourceDeleteLogsDeletedValue(test *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+	}{
+		{"value", map[string]interface{}{"value": "passbase"}, `deleted_value="passbase"`},
+		{"sensitive", map[string]interface{}{"value": "passbase", "sensitive": true}, `deleted_value="(sensitive)"`},
+		{"compressed", map[string]interface{}{"value": "passbase", "compress": true}, `deleted_value="passbase"`},
+	} {
+		test.Run(tc.name, func(test *testing.T) {
+			kv := newFakeKV()
+			client := newFakeClient(kv)
+			config := map[string]interface{}{"key": "/app/name", "log_deleted_value": true}
+			for name, value := range tc.config {
+				config[name] = value
+			}
+			state, diags := applyTestResource(test, KvResource(), nil, config, client)
+			if diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+
+			lines := captureLogs(func() {
+				_, diags = applyTestResource(test, KvResource(), state, nil, client)
+			})
+			if diags.HasError() {
+				test.Fatalf("err: %v", diags)
+			}
+			for _, line := range lines {
+				if strings.Contains(line, "[INFO]") && strings.Contains(line, `operation="delete"`) && strings.Contains(line, tc.expected) && strings.Contains(line, "deleted_mod_revision=2") {
+					return
+				}
+			}
+			test.Fatalf("expected the deleted value to be logged as %s, got:\n%s", tc.expected, strings.Join(lines, "\n"))
+		})
+	}
+}
+
+func TestKvResourceMoveKey(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+
+	r := KvResource()
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":   "/app/old",
+		"value": "passbase",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	config := map[string]interface{}{
+		"key":   "/app/new",
+		"value": "passbase",
+	}
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil || diff.RequiresNew() {
+		test.Fatalf("expected the key to be moved in place, got %v (%v)", diff, err)
+	}
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/new", "passbase")
+	if response, _ := kv.Get(ctx, "/app/old"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the old key to be deleted")
+	}
+	if state.ID != "/app/new" || state.Attributes["key"] != "/app/new" {
+		test.Fatalf("expected the new key in state, got %v", state)
+	}
+
+	// The value is written to the new key and removed from the old one at
+	// the same revision, so it is never missing from both.
+	revisions := map[string]int64{}
+	for _, event := range kv.events {
+		revisions[event.Type.String()+" "+string(event.Kv.Key)] = event.Kv.ModRevision
+	}
+	if revisions["PUT /app/new"] == 0 || revisions["PUT /app/new"] != revisions["DELETE /app/old"] {
+		test.Fatalf("expected the move to happen in a single revision, got %v", revisions)
+	}
+
+	// A destination that already exists is left alone.
+	kv.Put(ctx, "/app/taken", "other")
+	_, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":   "/app/taken",
+		"value": "passbase",
+	}, client)
+	if !diags.HasError() || diags[0].Summary != "Key already exists" {
+		test.Fatalf("expected the existing destination to fail the move, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/new", "passbase")
+	assertKeyValue(test, kv, "/app/taken", "other")
+
+	// if_value guards the key being moved.
+	_, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":      "/app/moved",
+		"value":    "awesome",
+		"if_value": "stale",
+	}, client)
+	if !diags.HasError() || diags[0].Summary != "Key does not hold the expected value" || !strings.Contains(diags[0].Detail, `it holds "passbase"`) {
+		test.Fatalf("expected if_value to fail the move, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/new", "passbase")
+	if response, _ := kv.Get(ctx, "/app/moved"); len(response.Kvs) != 0 {
+		test.Fatalf("expected nothing to be moved")
+	}
+
+	// The moved key is read back with verify_write.
+	client.KV = &corruptingKV{fakeKV: kv}
+	_, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"key":          "/app/moved",
+		"value":        "passbase",
+		"verify_write": true,
+	}, client)
+	if !diags.HasError() || diags[0].Summary != "Write verification failed" || !strings.Contains(diags[0].Detail, `"/app/moved"`) {
+		test.Fatalf("expected the move verification to fail, got %v", diags)
+	}
+}
+
+// corruptingKV stands for a proxy altering the values read through it.
+type corruptingKV struct {
+	*fakeKV
+}
+
+func (kv *corruptingKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	response, err := kv.fakeKV.Get(ctx, key, opts...)
+	if err != nil || len(response.Kvs) == 0 {
+		return response, err
+	}
+	altered := *response.Kvs[0]
+	altered.Value = []byte("tampered")
+	response.Kvs = []*mvccpb.KeyValue{&altered}
+	return response, nil
+}
+
+func TestKvResourceVerifyWrite(test *testing.T) {
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	r := KvResource()
+
+	config := map[string]interface{}{
+		"key":          "/app/name",
+		"value":        "passbase",
+		"verify_write": true,
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("expected the write to be verified, got %v", diags)
+	}
+
+	client.KV = &corruptingKV{fakeKV: kv}
+	config["value"] = "awesome"
+	_, diags = applyTestResource(test, r, state, config, client)
+	if !diags.HasError() || diags[0].Summary != "Write verification failed" || !strings.Contains(diags[0].Detail, `it holds "tampered"`) {
+		test.Fatalf("expected the update verification to fail, got %v", diags)
+	}
+
+	_, diags = applyTestResource(test, r, nil, map[string]interface{}{
+		"key":          "/app/other",
+		"value":        "passbase",
+		"verify_write": true,
+	}, client)
+	if !diags.HasError() || diags[0].Summary != "Write verification failed" {
+		test.Fatalf("expected the create verification to fail, got %v", diags)
+	}
+}
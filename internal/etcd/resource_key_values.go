@@ -0,0 +1,268 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+)
+
+func KvBatchResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages many keys at once, written in batched transactions.",
+
+		CreateContext: KvBatchCreate,
+		ReadContext:   KvBatchRead,
+		UpdateContext: KvBatchUpdate,
+		DeleteContext: KvBatchDelete,
+
+		Schema: map[string]*schema.Schema{
+			"pairs": &schema.Schema{
+				Type:        schema.TypeMap,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Values by key.",
+			},
+			"max_txn_ops": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      128,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of operations per transaction, at most the `--max-txn-ops` of the cluster.",
+			},
+		},
+	}
+}
+
+// sortedPairKeys returns the keys of pairs in order, so batches are stable.
+func sortedPairKeys(pairs map[string]interface{}) []string {
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// commitBatches commits ops in transactions of at most size operations, up
+// to client.parallelism of them at once. Every key appears in a single
+// operation, so the order the batches are committed in does not matter. The
+// first failure cancels the batches not committed yet, earlier batches stay
+// committed. The responses are in the order of the batches.
+func commitBatches(ctx context.Context, client *apiClient, ops []clientv3.Op, size int) ([]*clientv3.TxnResponse, error) {
+	batches := [][]clientv3.Op{}
+	for start := 0; start < len(ops); start += size {
+		end := start + size
+		if end > len(ops) {
+			end = len(ops)
+		}
+		batches = append(batches, ops[start:end])
+	}
+
+	parallelism := client.parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    []error
+		workers = make(chan struct{}, parallelism)
+	)
+	responses := make([]*clientv3.TxnResponse, len(batches))
+	for i, batch := range batches {
+		workers <- struct{}{}
+		if batchCtx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, batch []clientv3.Op) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			response, err := commitBatch(batchCtx, client, batch)
+			if err == nil {
+				responses[i] = response
+				return
+			}
+			// Batches cancelled by an earlier failure are not reported.
+			mu.Lock()
+			if len(errs) == 0 || !errors.Is(err, context.Canceled) {
+				errs = append(errs, err)
+			}
+			mu.Unlock()
+			cancel()
+		}(i, batch)
+	}
+	wg.Wait()
+
+	switch {
+	case len(errs) == 0 && ctx.Err() != nil:
+		return nil, ctx.Err()
+	case len(errs) == 1:
+		return nil, errs[0]
+	case len(errs) > 1:
+		others := []string{}
+		for _, err := range errs[1:] {
+			others = append(others, err.Error())
+		}
+		return nil, fmt.Errorf("%w, and %d more batches failed: %s", errs[0], len(others), strings.Join(others, "; "))
+	}
+	return responses, nil
+}
+
+// commitBatch commits ops in a single transaction. When etcd rejects it for
+// one of its operations, each operation is checked on its own to report the
+// key that failed.
+func commitBatch(ctx context.Context, client *apiClient, ops []clientv3.Op) (*clientv3.TxnResponse, error) {
+	response, err := client.Txn(ctx).Then(ops...).Commit()
+	if err == nil {
+		return response, nil
+	}
+	if ctx.Err() != nil {
+		return nil, err
+	}
+	if err == rpctypes.ErrTooManyOps {
+		return nil, fmt.Errorf("%v, lower max_txn_ops to at most the --max-txn-ops of the cluster", err)
+	}
+	if rejectedOperation(err) {
+		for _, op := range ops {
+			// etcd checks the permissions and size of the operations of
+			// both branches, but only applies those of the branch taken:
+			// with no comparison, an operation in the failure branch is
+			// checked without being applied.
+			if _, opErr := client.Txn(ctx).Else(op).Commit(); opErr != nil {
+				return nil, fmt.Errorf("key %q: %v", op.KeyBytes(), opErr)
+			}
+		}
+	}
+	return nil, fmt.Errorf("batch of keys %q to %q: %v", ops[0].KeyBytes(), ops[len(ops)-1].KeyBytes(), err)
+}
+
+// rejectedOperation reports whether etcd rejected a request because of one
+// of its operations, rather than failing to serve it.
+func rejectedOperation(err error) bool {
+	var etcdErr rpctypes.EtcdError
+	if !errors.As(err, &etcdErr) {
+		return false
+	}
+	switch etcdErr.Code() {
+	case codes.PermissionDenied, codes.InvalidArgument, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+func KvBatchCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	pairs := d.Get("pairs").(map[string]interface{})
+	ops := []clientv3.Op{}
+	for _, key := range sortedPairKeys(pairs) {
+		ops = append(ops, clientv3.OpPut(key, pairs[key].(string)))
+	}
+
+	logf(ctx, "DEBUG", "writing %d keys", len(ops))
+	responses, err := commitBatches(ctx, client, ops, d.Get("max_txn_ops").(int))
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to write keys: %w", err))
+	}
+
+	// The revision of the first batch identifies the resource, like etcd_txn.
+	id := "0"
+	if len(responses) > 0 {
+		id = strconv.FormatInt(responses[0].Header.Revision, 10)
+	}
+	d.SetId(id)
+	return KvBatchRead(ctx, d, meta)
+}
+
+// KvBatchRead reads every managed key back, so keys changed or deleted
+// outside of Terraform show up as a diff on pairs.
+func KvBatchRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	ops := []clientv3.Op{}
+	for _, key := range sortedPairKeys(d.Get("pairs").(map[string]interface{})) {
+		ops = append(ops, clientv3.OpGet(key))
+	}
+
+	responses, err := commitBatches(ctx, client, ops, d.Get("max_txn_ops").(int))
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to read keys: %w", err))
+	}
+
+	pairs := map[string]interface{}{}
+	for _, response := range responses {
+		for _, op := range response.Responses {
+			for _, kv := range op.GetResponseRange().Kvs {
+				pairs[string(kv.Key)] = string(kv.Value)
+			}
+		}
+	}
+
+	if err := d.Set("pairs", pairs); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func KvBatchUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	old, new := d.GetChange("pairs")
+	previous := old.(map[string]interface{})
+	current := new.(map[string]interface{})
+
+	ops := []clientv3.Op{}
+	for _, key := range sortedPairKeys(previous) {
+		if _, ok := current[key]; !ok {
+			ops = append(ops, clientv3.OpDelete(key))
+		}
+	}
+	for _, key := range sortedPairKeys(current) {
+		if previous[key] != current[key] {
+			ops = append(ops, clientv3.OpPut(key, current[key].(string)))
+		}
+	}
+
+	logf(ctx, "DEBUG", "updating %d keys", len(ops))
+	if _, err := commitBatches(ctx, client, ops, d.Get("max_txn_ops").(int)); err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to update keys: %w", err))
+	}
+	return KvBatchRead(ctx, d, meta)
+}
+
+func KvBatchDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	ops := []clientv3.Op{}
+	for _, key := range sortedPairKeys(d.Get("pairs").(map[string]interface{})) {
+		ops = append(ops, clientv3.OpDelete(key))
+	}
+
+	logf(ctx, "DEBUG", "deleting %d keys", len(ops))
+	if _, err := commitBatches(ctx, client, ops, d.Get("max_txn_ops").(int)); err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to delete keys: %w", err))
+	}
+	d.SetId("")
+	return nil
+}
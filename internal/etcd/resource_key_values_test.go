@@ -0,0 +1,180 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestKvBatchResource(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.maxTxnOps = 128
+	client := newFakeClient(kv)
+
+	pairs := map[string]interface{}{}
+	for i := 0; i < 500; i++ {
+		pairs[fmt.Sprintf("/app/%03d", i)] = fmt.Sprint(i)
+	}
+	state, diags := applyTestResource(test, KvBatchResource(), nil, map[string]interface{}{"pairs": pairs}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	// 4 transactions to write the keys and 4 to read them back.
+	if kv.txns != 8 {
+		test.Fatalf("expected the keys to be written in 4 transactions, got %d", kv.txns)
+	}
+	if state.Attributes["pairs.%"] != "500" {
+		test.Fatalf("expected 500 keys in state, got %s", state.Attributes["pairs.%"])
+	}
+	assertKeyValue(test, kv, "/app/499", "499")
+
+	// Keys changed or deleted outside of Terraform are detected per key.
+	kv.Put(ctx, "/app/010", "drifted")
+	kv.Delete(ctx, "/app/020")
+	state, diags = KvBatchResource().RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["pairs./app/010"] != "drifted" || state.Attributes["pairs.%"] != "499" {
+		test.Fatalf("expected the drift to be read back, got %s and %s keys", state.Attributes["pairs./app/010"], state.Attributes["pairs.%"])
+	}
+
+	delete(pairs, "/app/030")
+	pairs["/app/040"] = "changed"
+	state, diags = applyTestResource(test, KvBatchResource(), state, map[string]interface{}{"pairs": pairs}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/010", "10")
+	assertKeyValue(test, kv, "/app/020", "20")
+	assertKeyValue(test, kv, "/app/040", "changed")
+	if response, _ := kv.Get(ctx, "/app/030"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the removed key to be deleted")
+	}
+
+	if _, diags := applyTestResource(test, KvBatchResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if response, _ := kv.Get(ctx, "/app/", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+		test.Fatalf("expected every key to be deleted, %d left", len(response.Kvs))
+	}
+}
+
+func TestKvBatchResourceReportsFailedKey(test *testing.T) {
+	kv := newFakeKV()
+	// The batch fails, then the third key fails on its own.
+	kv.errs = []error{rpctypes.ErrPermissionDenied, nil, nil, rpctypes.ErrPermissionDenied}
+
+	_, diags := applyTestResource(test, KvBatchResource(), nil, map[string]interface{}{
+		"pairs": map[string]interface{}{"/app/a": "1", "/app/b": "2", "/app/c": "3", "/app/d": "4"},
+	}, newFakeClient(kv))
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, `"/app/c"`) {
+		test.Fatalf("expected the failed key to be reported, got %v", diags)
+	}
+	// Finding the failed key does not write the batch outside of its
+	// transaction.
+	if response, _ := kv.Get(context.Background(), "/app/", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+		test.Fatalf("expected no key to be written, %d were", len(response.Kvs))
+	}
+}
+
+func TestKvBatchResourceUnavailableWritesNothing(test *testing.T) {
+	kv := newFakeKV()
+	kv.errs = []error{rpctypes.ErrNoLeader}
+
+	_, diags := applyTestResource(test, KvBatchResource(), nil, map[string]interface{}{
+		"pairs": map[string]interface{}{"/app/a": "1", "/app/b": "2"},
+	}, newFakeClient(kv))
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "batch of keys") {
+		test.Fatalf("expected the failed batch to be reported, got %v", diags)
+	}
+	if response, _ := kv.Get(context.Background(), "/app/", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+		test.Fatalf("expected no key to be written, %d were", len(response.Kvs))
+	}
+}
+
+func TestKvBatchResourceTooManyOps(test *testing.T) {
+	kv := newFakeKV()
+	kv.maxTxnOps = 2
+
+	_, diags := applyTestResource(test, KvBatchResource(), nil, map[string]interface{}{
+		"pairs":       map[string]interface{}{"/app/a": "1", "/app/b": "2", "/app/c": "3"},
+		"max_txn_ops": 3,
+	}, newFakeClient(kv))
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "max_txn_ops") {
+		test.Fatalf("expected a hint to lower max_txn_ops, got %v", diags)
+	}
+}
+
+func TestKvBatchResourceParallel(test *testing.T) {
+	kv := newFakeKV()
+	kv.maxTxnOps = 16
+	client := newFakeClient(kv)
+	client.parallelism = 8
+
+	pairs := map[string]interface{}{}
+	for i := 0; i < 1000; i++ {
+		pairs[fmt.Sprintf("/app/%04d", i)] = fmt.Sprint(i)
+	}
+	state, diags := applyTestResource(test, KvBatchResource(), nil, map[string]interface{}{
+		"pairs":       pairs,
+		"max_txn_ops": 16,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["pairs.%"] != "1000" {
+		test.Fatalf("expected 1000 keys in state, got %s", state.Attributes["pairs.%"])
+	}
+	for i := 0; i < 1000; i++ {
+		assertKeyValue(test, kv, fmt.Sprintf("/app/%04d", i), fmt.Sprint(i))
+	}
+}
+
+func TestKvBatchResourceParallelFailureCancels(test *testing.T) {
+	kv := newFakeKV()
+	kv.errs = []error{rpctypes.ErrPermissionDenied}
+	client := newFakeClient(kv)
+	client.parallelism = 2
+
+	pairs := map[string]interface{}{}
+	for i := 0; i < 100; i++ {
+		pairs[fmt.Sprintf("/app/%03d", i)] = fmt.Sprint(i)
+	}
+	_, diags := applyTestResource(test, KvBatchResource(), nil, map[string]interface{}{
+		"pairs":       pairs,
+		"max_txn_ops": 1,
+	}, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "permission denied") {
+		test.Fatalf("expected the failed batch to be reported, got %v", diags)
+	}
+	if kv.txns >= 100 {
+		test.Fatalf("expected the failure to cancel the remaining batches, %d were committed", kv.txns)
+	}
+}
+
+func BenchmarkKvBatchCreate(b *testing.B) {
+	pairs := map[string]interface{}{}
+	for i := 0; i < 1000; i++ {
+		pairs[fmt.Sprintf("/app/%04d", i)] = fmt.Sprint(i)
+	}
+	for _, parallelism := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				client := newFakeClient(newFakeKV())
+				client.parallelism = parallelism
+				d := KvBatchResource().TestResourceData()
+				d.Set("pairs", pairs)
+				d.Set("max_txn_ops", 16)
+				if diags := KvBatchCreate(context.Background(), d, client); diags.HasError() {
+					b.Fatalf("err: %v", diags)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func KeyWaitResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Blocks the apply until a key holds an expected value.",
+
+		CreateContext: KeyWaitCreate,
+		ReadContext:   KeyWaitRead,
+		DeleteContext: KeyWaitDelete,
+
+		Schema: map[string]*schema.Schema{
+			"key": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"expected_value": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "5m",
+				ValidateFunc: validateDuration,
+				Description:  "How long to wait for the expected value, as a duration such as `30s`.",
+			},
+			"mod_revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Revision at which the key was seen holding the expected value.",
+			},
+		},
+	}
+}
+
+// KeyWaitCreate returns as soon as the key holds the expected value, watching
+// it from the revision of the initial read so no write is missed in between.
+func KeyWaitCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	key := d.Get("key").(string)
+	expected := d.Get("expected_value").(string)
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := client.Get(waitCtx, key)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if len(response.Kvs) > 0 && string(response.Kvs[0].Value) == expected {
+		return keyWaitSatisfied(d, key, response.Kvs[0].ModRevision)
+	}
+
+	logf(ctx, "DEBUG", "waiting up to %s for key %q to hold the expected value", timeout, key)
+	watch := client.Watch(clientv3.WithRequireLeader(waitCtx), key, clientv3.WithRev(response.Header.Revision+1))
+	for response := range watch {
+		if err := response.Err(); err != nil && waitCtx.Err() == nil {
+			return classifyEtcdError(err)
+		}
+		for _, event := range response.Events {
+			if event.Type == mvccpb.PUT && string(event.Kv.Value) == expected {
+				return keyWaitSatisfied(d, key, event.Kv.ModRevision)
+			}
+		}
+	}
+
+	// The watch channel closes when the timeout elapsed, but also when the
+	// Terraform operation itself was cancelled.
+	if err := ctx.Err(); err != nil {
+		return classifyEtcdError(err)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Timed out waiting for key",
+		Detail:   fmt.Sprintf("The key %q did not hold the expected value within %s.", key, timeout),
+	}}
+}
+
+func keyWaitSatisfied(d *schema.ResourceData, key string, rev int64) diag.Diagnostics {
+	if err := d.Set("mod_revision", rev); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(key)
+	return nil
+}
+
+// KeyWaitRead keeps the resource in state, the wait only happens on create.
+func KeyWaitRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// KeyWaitDelete only forgets the resource, the key is left untouched.
+func KeyWaitDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,75 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+func TestKeyWaitAlreadySatisfied(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/deploy/status", "ready")
+
+	state, diags := applyTestResource(test, KeyWaitResource(), nil, map[string]interface{}{
+		"key":            "/deploy/status",
+		"expected_value": "ready",
+		"timeout":        "1s",
+	}, newFakeWatchClient(kv))
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.ID != "/deploy/status" || state.Attributes["mod_revision"] != "2" {
+		test.Fatalf("expected the key to be satisfied at revision 2, got %v", state)
+	}
+}
+
+func TestKeyWaitWatchesForValue(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeWatchClient(kv)
+	kv.Put(ctx, "/deploy/status", "pending")
+
+	done := make(chan diag.Diagnostics)
+	go func() {
+		state, diags := applyTestResource(test, KeyWaitResource(), nil, map[string]interface{}{
+			"key":            "/deploy/status",
+			"expected_value": "ready",
+			"timeout":        "5s",
+		}, client)
+		if !diags.HasError() && state.Attributes["mod_revision"] != "5" {
+			diags = diag.Errorf("expected the key to be satisfied at revision 5, got %v", state)
+		}
+		done <- diags
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	kv.Put(ctx, "/deploy/other", "ready")
+	kv.Put(ctx, "/deploy/status", "starting")
+	kv.Put(ctx, "/deploy/status", "ready")
+
+	select {
+	case diags := <-done:
+		if diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+	case <-time.After(5 * time.Second):
+		test.Fatalf("the wait did not return after the key reached the expected value")
+	}
+}
+
+func TestKeyWaitTimeout(test *testing.T) {
+	kv := newFakeKV()
+	kv.Put(context.Background(), "/deploy/status", "pending")
+
+	_, diags := applyTestResource(test, KeyWaitResource(), nil, map[string]interface{}{
+		"key":            "/deploy/status",
+		"expected_value": "ready",
+		"timeout":        "100ms",
+	}, newFakeWatchClient(kv))
+	if !diags.HasError() || diags[0].Summary != "Timed out waiting for key" {
+		test.Fatalf("expected a timeout, got %v", diags)
+	}
+}
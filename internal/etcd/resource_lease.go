@@ -0,0 +1,150 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func LeaseResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Grants a lease that keys can be attached to, the keys are deleted when the lease expires or is revoked.",
+
+		CreateContext: LeaseResourceCreate,
+		ReadContext:   LeaseResourceRead,
+		UpdateContext: LeaseResourceUpdate,
+		DeleteContext: LeaseResourceDelete,
+
+		Schema: map[string]*schema.Schema{
+			"ttl": &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "TTL of the lease in seconds.",
+			},
+			"keep_alive": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Keep the lease alive in the background for as long as the Terraform process runs.",
+			},
+			"lease_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the lease.",
+			},
+			"granted_ttl": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "TTL granted by etcd, which may be raised to the cluster's minimum TTL.",
+			},
+			"remaining_ttl": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Seconds left before the lease expires, as of the last refresh.",
+			},
+		},
+	}
+}
+
+func LeaseResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	ttl := d.Get("ttl").(int)
+	logf(ctx, "DEBUG", "granting lease with a TTL of %ds", ttl)
+	response, err := client.Grant(ctx, int64(ttl))
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	logf(ctx, "DEBUG", "granted lease %s", formatLeaseID(response.ID))
+
+	if d.Get("keep_alive").(bool) {
+		if err := client.keepAlives.add(response.ID, leaseKeepAliveOwner); err != nil {
+			return diag.Errorf("unable to keep lease %s alive: %v", formatLeaseID(response.ID), err)
+		}
+	}
+
+	d.SetId(formatLeaseID(response.ID))
+	return LeaseResourceRead(ctx, d, meta)
+}
+
+func LeaseResourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	id, err := parseLeaseID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	response, err := client.TimeToLive(ctx, id)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	// etcd reports a TTL of -1 for leases that expired or were revoked.
+	if response.TTL <= 0 {
+		logf(ctx, "DEBUG", "lease %s expired, removing it from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	// Every Terraform run starts a new provider process, resume keeping the
+	// lease alive for this one.
+	if d.Get("keep_alive").(bool) {
+		if err := client.keepAlives.add(id, leaseKeepAliveOwner); err != nil {
+			return diag.Errorf("unable to keep lease %s alive: %v", d.Id(), err)
+		}
+	}
+
+	if err := d.Set("lease_id", d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("granted_ttl", response.GrantedTTL); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("remaining_ttl", response.TTL); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// leaseKeepAliveOwner owns the keep-alives of etcd_lease, a lease is
+// managed by a single etcd_lease.
+const leaseKeepAliveOwner = "etcd_lease"
+
+func LeaseResourceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	id, err := parseLeaseID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !d.Get("keep_alive").(bool) {
+		client.keepAlives.remove(id, leaseKeepAliveOwner)
+	}
+	return LeaseResourceRead(ctx, d, meta)
+}
+
+func LeaseResourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	id, err := parseLeaseID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	client.keepAlives.remove(id, leaseKeepAliveOwner)
+
+	logf(ctx, "DEBUG", "revoking lease %s", d.Id())
+	if _, err := client.Revoke(ctx, id); err != nil && err != rpctypes.ErrLeaseNotFound {
+		return classifyEtcdError(err)
+	}
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,132 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestLeaseResourceLifecycle(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+
+	d := schema.TestResourceDataRaw(test, LeaseResource().Schema, map[string]interface{}{
+		"ttl": 60,
+	})
+	if diags := LeaseResourceCreate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if d.Id() == "" || d.Get("lease_id").(string) != d.Id() {
+		test.Fatalf("expected lease_id to match the ID, got %q and %q", d.Get("lease_id"), d.Id())
+	}
+	id, _ := parseLeaseID(d.Id())
+	kv.Put(ctx, "/app/worker", "alive", clientv3.WithLease(id))
+
+	// Pretend part of the TTL elapsed.
+	lease.leases[id].ttl = 42
+	if diags := LeaseResourceRead(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got := d.Get("remaining_ttl").(int); got != 42 {
+		test.Fatalf("remaining_ttl: expected 42, got %d", got)
+	}
+	if got := d.Get("granted_ttl").(int); got != 60 {
+		test.Fatalf("granted_ttl: expected 60, got %d", got)
+	}
+
+	if diags := LeaseResourceDelete(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if response, _ := kv.Get(ctx, "/app/worker"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the attached key to be deleted with the lease")
+	}
+}
+
+func TestLeaseResourceReadExpired(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+
+	d := schema.TestResourceDataRaw(test, LeaseResource().Schema, map[string]interface{}{
+		"ttl": 5,
+	})
+	if diags := LeaseResourceCreate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	id, _ := parseLeaseID(d.Id())
+	lease.expire(id)
+
+	if diags := LeaseResourceRead(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if d.Id() != "" {
+		test.Fatalf("expected the expired lease to be removed from state")
+	}
+	// Destroying a lease that already expired must not fail.
+	d.SetId(formatLeaseID(id))
+	if diags := LeaseResourceDelete(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+}
+
+func TestLeaseResourceKeepAlive(test *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	client.keepAlives = newKeepAliveManager(ctx, lease)
+
+	r := LeaseResource()
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"ttl":        60,
+		"keep_alive": true,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	id, _ := parseLeaseID(state.ID)
+	if _, _, tracked := client.keepAlives.status(id); !tracked {
+		test.Fatalf("expected lease %s to be kept alive", state.ID)
+	}
+	if lease.leases[id].keepAlives == 0 {
+		test.Fatalf("expected lease %s to be refreshed", state.ID)
+	}
+
+	state, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"ttl":        60,
+		"keep_alive": false,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if _, _, tracked := client.keepAlives.status(id); tracked {
+		test.Fatalf("expected lease %s to no longer be kept alive", state.ID)
+	}
+
+	// Re-enabling it and stopping the provider must stop the keep-alive.
+	if _, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"ttl":        60,
+		"keep_alive": true,
+	}, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	stop()
+	done := make(chan struct{})
+	go func() {
+		client.keepAlives.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		test.Fatalf("keep-alive goroutine did not stop with the provider")
+	}
+}
@@ -0,0 +1,141 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// heldLock is a lock acquired by this provider process.
+type heldLock struct {
+	session *concurrency.Session
+	mutex   *concurrency.Mutex
+}
+
+func LockResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Acquires a distributed lock for as long as the provider process runs, using an etcd session and mutex.",
+
+		CreateContext: LockResourceCreate,
+		ReadContext:   LockResourceRead,
+		DeleteContext: LockResourceDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Key prefix of the lock, every holder of a lock with the same name is serialized.",
+			},
+			"ttl": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      60,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "TTL in seconds of the session lease, bounding how long an orphaned lock is held after the provider exits.",
+			},
+			"lease_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// sessionContext returns the context sessions live in, the lifetime of the
+// etcd client.
+func (c *apiClient) sessionContext() context.Context {
+	if ctx := c.Ctx(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+func LockResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	name := d.Get("name").(string)
+
+	session, err := concurrency.NewSession(client.Client,
+		concurrency.WithTTL(d.Get("ttl").(int)),
+		concurrency.WithContext(client.sessionContext()))
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	mutex := concurrency.NewMutex(session, name)
+	logf(ctx, "DEBUG", "acquiring lock %q", name)
+	if err := mutex.Lock(ctx); err != nil {
+		session.Close()
+		return diag.Errorf("unable to acquire lock %q: %v", name, err)
+	}
+	logf(ctx, "DEBUG", "acquired lock %q as %q", name, mutex.Key())
+
+	client.locks.Store(mutex.Key(), &heldLock{session: session, mutex: mutex})
+	d.SetId(mutex.Key())
+	if err := d.Set("lease_id", formatLeaseID(session.Lease())); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func LockResourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	if _, ok := client.locks.Load(d.Id()); ok {
+		return nil
+	}
+
+	// Held by a provider process that exited, the lock lives as long as
+	// its session lease.
+	id, err := parseLeaseID(d.Get("lease_id").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	response, err := client.TimeToLive(ctx, id)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if response.TTL <= 0 {
+		logf(ctx, "DEBUG", "session of lock %q expired, removing it from state", d.Id())
+		d.SetId("")
+	}
+	return nil
+}
+
+func LockResourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	logf(ctx, "DEBUG", "releasing lock %q", d.Id())
+	if held, ok := client.locks.Load(d.Id()); ok {
+		lock := held.(*heldLock)
+		if err := lock.mutex.Unlock(ctx); err != nil {
+			return classifyEtcdError(err)
+		}
+		if err := lock.session.Close(); err != nil && err != rpctypes.ErrLeaseNotFound {
+			return classifyEtcdError(err)
+		}
+		client.locks.Delete(d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	// Revoking the session lease deletes the lock key.
+	id, err := parseLeaseID(d.Get("lease_id").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if _, err := client.Revoke(ctx, id); err != nil && err != rpctypes.ErrLeaseNotFound {
+		return classifyEtcdError(err)
+	}
+	d.SetId("")
+	return nil
+}
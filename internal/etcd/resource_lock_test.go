@@ -0,0 +1,62 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestLockResourceSerializesHolders(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	first := newFakeLeaseClient(kv, lease)
+	first.Watcher = &fakeWatcher{kv: kv}
+	second := newFakeLeaseClient(kv, lease)
+	second.Watcher = &fakeWatcher{kv: kv}
+
+	config := map[string]interface{}{"name": "/locks/deploy"}
+	held := schema.TestResourceDataRaw(test, LockResource().Schema, config)
+	if diags := LockResourceCreate(ctx, held, first); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	waiting := schema.TestResourceDataRaw(test, LockResource().Schema, config)
+	acquired := make(chan diag.Diagnostics)
+	go func() {
+		acquired <- LockResourceCreate(ctx, waiting, second)
+	}()
+
+	select {
+	case diags := <-acquired:
+		test.Fatalf("expected the second holder to wait, got %v", diags)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if diags := LockResourceDelete(ctx, held, first); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	select {
+	case diags := <-acquired:
+		if diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+	case <-time.After(5 * time.Second):
+		test.Fatalf("expected the second holder to acquire the lock once released")
+	}
+	if waiting.Id() == "" || waiting.Id() == held.Id() {
+		test.Fatalf("expected a distinct lock key, got %q", waiting.Id())
+	}
+
+	if diags := LockResourceDelete(ctx, waiting, second); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if response, _ := kv.Get(ctx, "/locks/deploy", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+		test.Fatalf("expected no lock keys left, got %v", response.Kvs)
+	}
+}
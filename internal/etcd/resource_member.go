@@ -0,0 +1,214 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func MemberResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Adds a member to the cluster, and removes it on destroy.",
+
+		CreateContext: MemberCreate,
+		ReadContext:   MemberRead,
+		DeleteContext: MemberDelete,
+
+		Schema: map[string]*schema.Schema{
+			"peer_urls": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				ForceNew: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"learner": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Add the member as a non-voting learner, to be promoted with `etcd_member_promote` once it caught up.",
+			},
+			"member_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Member ID in hexadecimal, as printed by etcdctl.",
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the member, empty until it started.",
+			},
+			"is_learner": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the member is still a learner.",
+			},
+		},
+	}
+}
+
+func MemberCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	peerURLs := []string{}
+	for _, url := range d.Get("peer_urls").([]interface{}) {
+		peerURLs = append(peerURLs, url.(string))
+	}
+
+	add := client.MemberAdd
+	if d.Get("learner").(bool) {
+		add = client.MemberAddAsLearner
+	}
+	response, err := add(ctx, peerURLs)
+	if err == rpctypes.ErrMemberNotEnoughStarted {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Not enough started members",
+			Detail:   "Adding the member would leave the cluster without quorum, because members added earlier have not started yet. Start the pending members before adding another one.",
+		}}
+	}
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to add a member with peer URLs %v: %w", peerURLs, err))
+	}
+
+	logf(ctx, "DEBUG", "added member %x", response.Member.ID)
+	d.SetId(strconv.FormatUint(response.Member.ID, 16))
+	return MemberRead(ctx, d, meta)
+}
+
+func MemberRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	member, err := findMember(ctx, client, d.Id())
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if member == nil {
+		logf(ctx, "DEBUG", "member %s was removed, removing it from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	if err := d.Set("member_id", d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("name", member.Name); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("is_learner", member.IsLearner); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func MemberDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	id, err := strconv.ParseUint(d.Id(), 16, 64)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if _, err := client.MemberRemove(ctx, id); err != nil && err != rpctypes.ErrMemberNotFound {
+		return classifyEtcdError(fmt.Errorf("unable to remove member %s: %w", d.Id(), err))
+	}
+	d.SetId("")
+	return nil
+}
+
+// findMember returns the member with the hexadecimal id, or nil when it is
+// not part of the cluster.
+func findMember(ctx context.Context, client *apiClient, id string) (*pb.Member, error) {
+	memberID, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.MemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range response.Members {
+		if member.ID == memberID {
+			return member, nil
+		}
+	}
+	return nil, nil
+}
+
+func MemberPromoteResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Promotes a learner member to a voting member.",
+
+		CreateContext: MemberPromoteCreate,
+		ReadContext:   MemberPromoteRead,
+		DeleteContext: MemberPromoteDelete,
+
+		Schema: map[string]*schema.Schema{
+			"member_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateMemberID,
+				Description:  "ID of the learner in hexadecimal, as printed by etcdctl.",
+			},
+		},
+	}
+}
+
+// MemberPromoteCreate promotes the learner. A member that is already voting
+// is left as is.
+func MemberPromoteCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	id := d.Get("member_id").(string)
+	memberID, _ := strconv.ParseUint(id, 16, 64)
+
+	_, err := client.MemberPromote(ctx, memberID)
+	switch err {
+	case nil:
+		logf(ctx, "DEBUG", "promoted member %s", id)
+	case rpctypes.ErrMemberNotLearner:
+		logf(ctx, "DEBUG", "member %s is already a voting member", id)
+	case rpctypes.ErrMemberLearnerNotReady:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Learner not ready",
+			Detail:   fmt.Sprintf("The learner %s has not caught up with the leader yet. Apply again once it is in sync.", id),
+		}}
+	default:
+		return classifyEtcdError(fmt.Errorf("unable to promote member %s: %w", id, err))
+	}
+	d.SetId(id)
+	return nil
+}
+
+// MemberPromoteRead forgets the promotion when the member left the cluster.
+func MemberPromoteRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	member, err := findMember(ctx, client, d.Id())
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if member == nil {
+		logf(ctx, "DEBUG", "member %s was removed, removing its promotion from state", d.Id())
+		d.SetId("")
+	}
+	return nil
+}
+
+// MemberPromoteDelete only forgets the resource, members cannot be demoted.
+func MemberPromoteDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,83 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func TestMemberResource(test *testing.T) {
+	ctx := context.Background()
+	cluster := &fakeCluster{members: []*pb.Member{{ID: 0x1, Name: "etcd-0"}}}
+	client := newFakeClient(newFakeKV())
+	client.Cluster = cluster
+
+	state, diags := applyTestResource(test, MemberResource(), nil, map[string]interface{}{
+		"peer_urls": []interface{}{"https://etcd-1:2380"},
+		"learner":   true,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.ID != "101" || state.Attributes["member_id"] != "101" || state.Attributes["is_learner"] != "true" {
+		test.Fatalf("expected the learner to be added, got %v", state.Attributes)
+	}
+
+	promotion, diags := applyTestResource(test, MemberPromoteResource(), nil, map[string]interface{}{
+		"member_id": state.ID,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if cluster.members[1].IsLearner {
+		test.Fatalf("expected the learner to be promoted")
+	}
+
+	// Promoting a voting member again is a no-op.
+	if _, diags := applyTestResource(test, MemberPromoteResource(), nil, map[string]interface{}{
+		"member_id": state.ID,
+	}, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	// The promotion does not force the member to be replaced.
+	state, diags = applyTestResource(test, MemberResource(), state, map[string]interface{}{
+		"peer_urls": []interface{}{"https://etcd-1:2380"},
+		"learner":   true,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if len(cluster.members) != 2 || state.ID != "101" {
+		test.Fatalf("expected the member to be kept, got %v", cluster.members)
+	}
+
+	if _, diags := applyTestResource(test, MemberResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if len(cluster.members) != 1 {
+		test.Fatalf("expected the member to be removed, got %v", cluster.members)
+	}
+
+	// Both resources are removed from state once the member left the cluster.
+	if refreshed, diags := MemberResource().RefreshWithoutUpgrade(ctx, state, client); diags.HasError() || refreshed != nil {
+		test.Fatalf("expected the removed member to leave state, got %v (%v)", refreshed, diags)
+	}
+	if refreshed, diags := MemberPromoteResource().RefreshWithoutUpgrade(ctx, promotion, client); diags.HasError() || refreshed != nil {
+		test.Fatalf("expected the promotion to leave state, got %v (%v)", refreshed, diags)
+	}
+}
+
+func TestMemberResourceNotEnoughStarted(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.Cluster = &fakeCluster{err: rpctypes.ErrMemberNotEnoughStarted}
+
+	_, diags := applyTestResource(test, MemberResource(), nil, map[string]interface{}{
+		"peer_urls": []interface{}{"https://etcd-1:2380"},
+	}, client)
+	if !diags.HasError() || diags[0].Summary != "Not enough started members" {
+		test.Fatalf("expected a not enough started members error, got %v", diags)
+	}
+}
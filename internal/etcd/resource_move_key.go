@@ -0,0 +1,115 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/clientv3util"
+)
+
+func MoveKeyResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Renames `source` to `destination` in a single transaction, so the value is never missing from both keys.",
+
+		CreateContext: MoveKeyCreate,
+		ReadContext:   MoveKeyRead,
+		DeleteContext: MoveKeyDelete,
+
+		Schema: map[string]*schema.Schema{
+			"source": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"destination": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Cluster revision of the move.",
+			},
+		},
+	}
+}
+
+func MoveKeyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	source := d.Get("source").(string)
+	destination := d.Get("destination").(string)
+	if source == destination {
+		return diag.Errorf("source and destination are both %q", source)
+	}
+
+	response, err := client.Get(ctx, source)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if len(response.Kvs) == 0 {
+		return diag.Errorf("source key %q does not exist", source)
+	}
+	kv := response.Kvs[0]
+
+	var opts []clientv3.OpOption
+	if lease := clientv3.LeaseID(kv.Lease); lease != clientv3.NoLease {
+		opts = append(opts, clientv3.WithLease(lease))
+	}
+
+	// The mod revision guard makes sure the value moved is the one read, and
+	// an existing destination is never overwritten.
+	logf(ctx, "DEBUG", "moving key %q to %q", source, destination)
+	txn, err := client.Txn(ctx).
+		If(
+			clientv3util.KeyExists(source),
+			clientv3.Compare(clientv3.ModRevision(source), "=", kv.ModRevision),
+			clientv3util.KeyMissing(destination),
+		).
+		Then(
+			clientv3.OpPut(destination, string(kv.Value), opts...),
+			clientv3.OpDelete(source),
+		).
+		Commit()
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	if !txn.Succeeded {
+		existing, err := client.Get(ctx, destination, clientv3.WithCountOnly())
+		if err == nil && existing.Count > 0 {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "Key already exists",
+				Detail:   fmt.Sprintf("The key %q could not be moved to %q, which already exists in etcd. Both keys were left unchanged.", source, destination),
+			}}
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Source key was modified concurrently",
+			Detail:   fmt.Sprintf("The key %q was written or deleted while it was being moved to %q, nothing was moved. Apply again to move its current value.", source, destination),
+		}}
+	}
+
+	d.SetId(fmt.Sprintf("%s:%s", source, destination))
+	if err := d.Set("revision", int(txn.Header.Revision)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// MoveKeyRead keeps the move in state, the keys are free to change once it
+// is done.
+func MoveKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// MoveKeyDelete only forgets the move, the key is not moved back.
+func MoveKeyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,64 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestMoveKeyResource(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	lease := newFakeLease(kv)
+	client := newFakeLeaseClient(kv, lease)
+	grant, _ := lease.Grant(ctx, 60)
+	kv.Put(ctx, "/app/old", "passbase", clientv3.WithLease(grant.ID))
+
+	state, diags := applyTestResource(test, MoveKeyResource(), nil, map[string]interface{}{
+		"source":      "/app/old",
+		"destination": "/app/new",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["source"] != "/app/old" || state.Attributes["destination"] != "/app/new" {
+		test.Fatalf("expected both keys in state, got %v", state.Attributes)
+	}
+
+	assertKeyValue(test, kv, "/app/new", "passbase")
+	response, _ := kv.Get(ctx, "/app/", clientv3.WithPrefix())
+	if len(response.Kvs) != 1 || clientv3.LeaseID(response.Kvs[0].Lease) != grant.ID {
+		test.Fatalf("expected only the destination to hold the value with its lease, got %v", response.Kvs)
+	}
+	if kv.txns != 1 {
+		test.Fatalf("expected the move to be a single transaction, got %d", kv.txns)
+	}
+}
+
+func TestMoveKeyResourceMissingSource(test *testing.T) {
+	_, diags := applyTestResource(test, MoveKeyResource(), nil, map[string]interface{}{
+		"source":      "/app/old",
+		"destination": "/app/new",
+	}, newFakeClient(newFakeKV()))
+	if !diags.HasError() {
+		test.Fatalf("expected the move of a missing key to fail")
+	}
+}
+
+func TestMoveKeyResourceExistingDestination(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	kv.Put(ctx, "/app/old", "passbase")
+	kv.Put(ctx, "/app/new", "existing")
+
+	_, diags := applyTestResource(test, MoveKeyResource(), nil, map[string]interface{}{
+		"source":      "/app/old",
+		"destination": "/app/new",
+	}, newFakeClient(kv))
+	if !diags.HasError() || diags[0].Summary != "Key already exists" {
+		test.Fatalf("expected the move onto an existing key to fail, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/old", "passbase")
+	assertKeyValue(test, kv, "/app/new", "existing")
+}
@@ -0,0 +1,308 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func PrefixResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Owns every key below a prefix, writing and removing them together in a single transaction.",
+
+		CreateContext: PrefixResourceCreate,
+		ReadContext:   PrefixResourceRead,
+		UpdateContext: PrefixResourceUpdate,
+		DeleteContext: PrefixResourceDelete,
+
+		// The import ID is the prefix, optionally written prefix:/app/.
+		Importer: &schema.ResourceImporter{
+			StateContext: PrefixResourceImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"prefix": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "Prefix of the keys. Changing it moves the keys to the new prefix.",
+			},
+			"values": &schema.Schema{
+				Type:             schema.TypeMap,
+				Required:         true,
+				Elem:             &schema.Schema{Type: schema.TypeString},
+				ValidateDiagFunc: validatePrefixValueNames,
+				Description:      "Values keyed by their name relative to `prefix`.",
+			},
+			"delete_protection": deleteProtectionSchema(),
+		},
+	}
+}
+
+// validatePrefixValueNames rejects the empty name, which would write the
+// prefix key itself instead of a key below it.
+func validatePrefixValueNames(v interface{}, path cty.Path) diag.Diagnostics {
+	for name := range v.(map[string]interface{}) {
+		if name == "" {
+			return diag.Errorf("value names must not be empty, the key equal to the prefix is not part of the subtree")
+		}
+	}
+	return nil
+}
+
+// subtree returns the key and options selecting every key below prefix. It
+// is WithPrefix without the key equal to the prefix, which is not owned by
+// the resource and cannot be represented in values.
+func subtree(prefix string) (string, []clientv3.OpOption) {
+	return prefix + "\x00", []clientv3.OpOption{clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefix))}
+}
+
+// prefixPageSize is how many keys are read per request when listing a
+// subtree.
+var prefixPageSize int64 = 1000
+
+// listSubtree reads every key below prefix, in pages of prefixPageSize keys
+// all read at the revision of the first page so they form a consistent view.
+func listSubtree(ctx context.Context, client *apiClient, prefix string) ([]*mvccpb.KeyValue, error) {
+	key, _ := subtree(prefix)
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	kvs := []*mvccpb.KeyValue{}
+	var revision int64
+	for {
+		opts := []clientv3.OpOption{
+			clientv3.WithRange(end),
+			clientv3.WithLimit(prefixPageSize),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+		}
+		if revision != 0 {
+			opts = append(opts, clientv3.WithRev(revision))
+		}
+		response, err := client.Get(ctx, key, opts...)
+		if err != nil {
+			return nil, err
+		}
+		revision = response.Header.Revision
+		kvs = append(kvs, response.Kvs...)
+		if !response.More || len(response.Kvs) == 0 {
+			return kvs, nil
+		}
+		// The next page starts right after the last key of this one.
+		key = string(response.Kvs[len(response.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// parsePrefixImportID returns the prefix of an import ID, either the prefix
+// itself or prefix:<prefix>.
+func parsePrefixImportID(id string) (string, error) {
+	prefix := strings.TrimPrefix(id, "prefix:")
+	if prefix == "" {
+		return "", fmt.Errorf("invalid import ID %q, expected a prefix such as prefix:/app/", id)
+	}
+	return prefix, nil
+}
+
+// PrefixResourceImport lists the keys below the prefix of the import ID into
+// values, so a whole existing tree is imported at once.
+func PrefixResourceImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	prefix, err := parsePrefixImportID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	kvs, err := listSubtree(ctx, client, prefix)
+	if err != nil {
+		return nil, err
+	}
+	// An empty subtree is most likely a mistyped prefix.
+	if len(kvs) == 0 {
+		return nil, fmt.Errorf("no key below prefix %q to import", prefix)
+	}
+	logf(ctx, "DEBUG", "importing %d keys below prefix %q", len(kvs), prefix)
+
+	values := map[string]interface{}{}
+	for _, kv := range kvs {
+		values[strings.TrimPrefix(string(kv.Key), prefix)] = string(kv.Value)
+	}
+	d.SetId(prefix)
+	if err := d.Set("prefix", prefix); err != nil {
+		return nil, err
+	}
+	if err := d.Set("values", values); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+func PrefixResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	prefix := d.Get("prefix").(string)
+
+	ops := []clientv3.Op{}
+	for name, value := range d.Get("values").(map[string]interface{}) {
+		ops = append(ops, clientv3.OpPut(prefix+name, value.(string)))
+	}
+
+	logf(ctx, "DEBUG", "writing %d keys below prefix %q", len(ops), prefix)
+	if _, err := client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		return classifyEtcdError(err)
+	}
+
+	d.SetId(prefix)
+	return PrefixResourceRead(ctx, d, meta)
+}
+
+func PrefixResourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	prefix := d.Id()
+
+	kvs, err := listSubtree(ctx, client, prefix)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	// Keys added or removed outside of Terraform show up as a diff on values.
+	values := map[string]interface{}{}
+	for _, kv := range kvs {
+		values[strings.TrimPrefix(string(kv.Key), prefix)] = string(kv.Value)
+	}
+
+	if err := d.Set("prefix", prefix); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("values", values); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func PrefixResourceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	if d.HasChange("prefix") {
+		return movePrefix(ctx, d, meta)
+	}
+
+	prefix := d.Get("prefix").(string)
+
+	old, new := d.GetChange("values")
+	previous := old.(map[string]interface{})
+	current := new.(map[string]interface{})
+
+	ops := []clientv3.Op{}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			ops = append(ops, clientv3.OpDelete(prefix+name))
+		}
+	}
+	for name, value := range current {
+		if previous[name] != value {
+			ops = append(ops, clientv3.OpPut(prefix+name, value.(string)))
+		}
+	}
+
+	logf(ctx, "DEBUG", "updating %d keys below prefix %q", len(ops), prefix)
+	if _, err := client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to update prefix %q: %w", prefix, err))
+	}
+
+	return PrefixResourceRead(ctx, d, meta)
+}
+
+// movePrefix moves the keys below the old prefix to the new one with the
+// changes to values applied, in a single transaction. The transaction only
+// succeeds while the new prefix holds no key and the old keys were not
+// written since they were read.
+func movePrefix(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	old, new := d.GetChange("prefix")
+	from, to := old.(string), new.(string)
+	if strings.HasPrefix(from, to) || strings.HasPrefix(to, from) {
+		return diag.Errorf("unable to move prefix %q to %q, one is below the other", from, to)
+	}
+
+	fromKey, fromOpts := subtree(from)
+	response, err := client.Get(ctx, fromKey, fromOpts...)
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	// The keys are copied as they are in etcd, then the changes to values
+	// are applied on top.
+	previous, current := d.GetChange("values")
+	values := map[string]string{}
+	for _, kv := range response.Kvs {
+		values[strings.TrimPrefix(string(kv.Key), from)] = string(kv.Value)
+	}
+	for name := range previous.(map[string]interface{}) {
+		if _, ok := current.(map[string]interface{})[name]; !ok {
+			delete(values, name)
+		}
+	}
+	for name, value := range current.(map[string]interface{}) {
+		values[name] = value.(string)
+	}
+
+	ops := []clientv3.Op{clientv3.OpDelete(fromKey, fromOpts...)}
+	for name, value := range values {
+		ops = append(ops, clientv3.OpPut(to+name, value))
+	}
+
+	toKey, _ := subtree(to)
+	toEnd := clientv3.GetPrefixRangeEnd(to)
+	fromEnd := clientv3.GetPrefixRangeEnd(from)
+	logf(ctx, "DEBUG", "moving %d keys from prefix %q to %q", len(values), from, to)
+	moved, err := client.Txn(ctx).
+		If(
+			clientv3.Compare(clientv3.CreateRevision(toKey), "=", 0).WithRange(toEnd),
+			clientv3.Compare(clientv3.ModRevision(fromKey), "<", response.Header.Revision+1).WithRange(fromEnd),
+		).
+		Then(ops...).
+		Commit()
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to move prefix %q to %q: %w", from, to, err))
+	}
+	if !moved.Succeeded {
+		existing, err := client.Get(ctx, toKey, clientv3.WithRange(toEnd), clientv3.WithCountOnly())
+		if err == nil && existing.Count > 0 {
+			return diag.Errorf("unable to move prefix %q to %q, the destination already holds %d keys", from, to, existing.Count)
+		}
+		return diag.Errorf("unable to move prefix %q to %q, its keys were written concurrently, apply again", from, to)
+	}
+
+	d.SetId(to)
+	return PrefixResourceRead(ctx, d, meta)
+}
+
+func PrefixResourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	prefix := d.Id()
+	if diags := checkDeleteProtection(d, fmt.Sprintf("the keys below prefix %q", prefix)); diags.HasError() {
+		return diags
+	}
+
+	logf(ctx, "DEBUG", "deleting keys below prefix %q", prefix)
+	key, opts := subtree(prefix)
+	if _, err := client.Txn(ctx).Then(clientv3.OpDelete(key, opts...)).Commit(); err != nil {
+		return classifyEtcdError(err)
+	}
+	d.SetId("")
+	return nil
+}
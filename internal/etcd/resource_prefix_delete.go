@@ -0,0 +1,142 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func PrefixDeleteResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Deletes every key under a prefix, or in a key range, when created, without owning them.",
+
+		CreateContext: PrefixDeleteCreate,
+		ReadContext:   PrefixDeleteRead,
+		DeleteContext: PrefixDeleteDelete,
+		CustomizeDiff: prefixDeleteCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"prefix": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"prefix", "key"},
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "Prefix of the keys to delete, including the key equal to it.",
+			},
+			"key": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "First key of the range to delete, instead of `prefix`.",
+			},
+			"range_end": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"key"},
+				Description:  "End of the range to delete, excluded, which must sort after `key`. Only `key` is deleted when unset.",
+			},
+			"confirm": &schema.Schema{
+				Type:         schema.TypeBool,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateConfirm,
+				Description:  "Must be set to `true`, acknowledging that every key under `prefix`, or in the range, is deleted.",
+			},
+			"triggers": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that delete the keys under the prefix again when they change.",
+			},
+			"deleted": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of keys deleted.",
+			},
+			"revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Cluster revision at which the keys were deleted.",
+			},
+		},
+	}
+}
+
+func validateConfirm(v interface{}, k string) ([]string, []error) {
+	if !v.(bool) {
+		return nil, []error{fmt.Errorf("%q must be true to delete every key under the prefix or in the range", k)}
+	}
+	return nil, nil
+}
+
+// prefixDeleteCustomizeDiff rejects empty ranges at plan time, etcd would
+// silently delete nothing.
+func prefixDeleteCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	key, rangeEnd := d.Get("key").(string), d.Get("range_end").(string)
+	if rangeEnd != "" && rangeEnd <= key {
+		return fmt.Errorf("range_end %q must sort after key %q", rangeEnd, key)
+	}
+	return nil
+}
+
+// deletedRange returns the key and options of the keys to delete, and their
+// description for messages.
+func deletedRange(d *schema.ResourceData) (string, []clientv3.OpOption, string) {
+	if prefix := d.Get("prefix").(string); prefix != "" {
+		return prefix, []clientv3.OpOption{clientv3.WithPrefix()}, fmt.Sprintf("the keys under prefix %q", prefix)
+	}
+	key := d.Get("key").(string)
+	if rangeEnd := d.Get("range_end").(string); rangeEnd != "" {
+		return key, []clientv3.OpOption{clientv3.WithRange(rangeEnd)}, fmt.Sprintf("the keys in range [%q, %q)", key, rangeEnd)
+	}
+	return key, nil, fmt.Sprintf("key %q", key)
+}
+
+// PrefixDeleteCreate deletes the keys under the prefix, or in the range, in a
+// single request. Deleting no key is not an error, the keys are gone either
+// way.
+func PrefixDeleteCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	key, opts, description := deletedRange(d)
+	if !d.Get("confirm").(bool) {
+		return diag.Errorf("refusing to delete %s without confirm = true", description)
+	}
+
+	logf(ctx, "DEBUG", "deleting %s", description)
+	response, err := client.Delete(ctx, key, opts...)
+	if err != nil {
+		return classifyEtcdError(fmt.Errorf("unable to delete %s: %w", description, err))
+	}
+	logf(ctx, "INFO", "deleted %d keys at revision %d: %s", response.Deleted, response.Header.Revision, description)
+
+	d.SetId(key)
+	if err := d.Set("deleted", int(response.Deleted)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("revision", int(response.Header.Revision)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// PrefixDeleteRead keeps the resource in state, keys written under the
+// prefix later are only deleted when the resource is replaced.
+func PrefixDeleteRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// PrefixDeleteDelete only forgets the resource, the deleted keys are gone.
+func PrefixDeleteDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
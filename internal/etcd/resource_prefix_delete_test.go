@@ -0,0 +1,88 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestPrefixDeleteResource(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	for i := 0; i < 5; i++ {
+		kv.Put(ctx, fmt.Sprintf("/tmp/job/%d", i), "done")
+	}
+	kv.Put(ctx, "/tmp/other", "kept")
+
+	r := PrefixDeleteResource()
+	config := map[string]interface{}{
+		"prefix":  "/tmp/job/",
+		"confirm": true,
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["deleted"] != "5" {
+		test.Fatalf("expected 5 keys to be deleted, got %v", state.Attributes)
+	}
+	if response, _ := kv.Get(ctx, "/tmp/job/", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+		test.Fatalf("expected the subtree to be empty, got %v", response.Kvs)
+	}
+	assertKeyValue(test, kv, "/tmp/other", "kept")
+
+	// An empty prefix is deleted again without error when the triggers change.
+	config["triggers"] = map[string]interface{}{"run": "2"}
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["deleted"] != "0" {
+		test.Fatalf("expected nothing left to delete, got %v", state.Attributes)
+	}
+
+	if diags := r.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{"prefix": "/tmp/", "confirm": false})); !diags.HasError() {
+		test.Fatalf("expected confirm = false to be rejected")
+	}
+}
+
+func TestPrefixDeleteResourceRange(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	for _, key := range []string{"a", "b", "c"} {
+		kv.Put(ctx, key, key)
+	}
+
+	r := PrefixDeleteResource()
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"key":       "a",
+		"range_end": "c",
+		"confirm":   true,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["deleted"] != "2" {
+		test.Fatalf("expected 2 keys to be deleted, got %v", state.Attributes)
+	}
+	for _, key := range []string{"a", "b"} {
+		if response, _ := kv.Get(ctx, key); len(response.Kvs) != 0 {
+			test.Fatalf("expected %q to be deleted", key)
+		}
+	}
+	assertKeyValue(test, kv, "c", "c")
+
+	_, diags = applyTestResource(test, r, nil, map[string]interface{}{
+		"key":       "c",
+		"range_end": "a",
+		"confirm":   true,
+	}, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "must sort after key") {
+		test.Fatalf("expected a range ending before its key to be rejected, got %v", diags)
+	}
+}
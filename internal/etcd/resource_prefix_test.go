@@ -0,0 +1,164 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestPrefixResourceLifecycle(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	// The key equal to the prefix is not part of the subtree.
+	kv.Put(ctx, "/app/config/", "root")
+
+	r := PrefixResource()
+	config := map[string]interface{}{
+		"prefix": "/app/config/",
+		"values": map[string]interface{}{"name": "passbase", "db/host": "localhost"},
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config/name", "passbase")
+	assertKeyValue(test, kv, "/app/config/db/host", "localhost")
+	if state.Attributes["values.%"] != "2" {
+		test.Fatalf("expected 2 values in state, got %v", state.Attributes)
+	}
+
+	// Drift: one key added and one removed outside of Terraform.
+	kv.Put(ctx, "/app/config/extra", "x")
+	kv.Delete(ctx, "/app/config/name")
+	state, diags = r.RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["values.extra"] != "x" {
+		test.Fatalf("expected the extra key to be detected, got %v", state.Attributes)
+	}
+	if _, ok := state.Attributes["values.name"]; ok {
+		test.Fatalf("expected the removed key to be detected, got %v", state.Attributes)
+	}
+
+	state, diags = applyTestResource(test, r, state, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/config/name", "passbase")
+	if response, _ := kv.Get(ctx, "/app/config/extra"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the extra key to be removed")
+	}
+
+	if _, diags = applyTestResource(test, r, state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	for _, key := range []string{"/app/config/name", "/app/config/db/host"} {
+		if response, _ := kv.Get(ctx, key); len(response.Kvs) != 0 {
+			test.Fatalf("expected %q to be deleted", key)
+		}
+	}
+	assertKeyValue(test, kv, "/app/config/", "root")
+}
+
+func TestPrefixResourceRejectsEmptyName(test *testing.T) {
+	diags := validatePrefixValueNames(map[string]interface{}{"": "value"}, nil)
+	if !diags.HasError() {
+		test.Fatalf("expected an empty value name to be rejected")
+	}
+}
+
+func TestPrefixResourceMove(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+
+	r := PrefixResource()
+	state, diags := applyTestResource(test, r, nil, map[string]interface{}{
+		"prefix": "/a/",
+		"values": map[string]interface{}{"name": "passbase", "db/host": "localhost"},
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	state, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"prefix": "/b/",
+		"values": map[string]interface{}{"name": "passbase", "db/host": "localhost"},
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.ID != "/b/" || state.Attributes["values.%"] != "2" {
+		test.Fatalf("expected the resource to move to /b/, got %v", state)
+	}
+	assertKeyValue(test, kv, "/b/name", "passbase")
+	assertKeyValue(test, kv, "/b/db/host", "localhost")
+	if response, _ := kv.Get(ctx, "/a/", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+		test.Fatalf("expected the keys below /a/ to be moved, %d left", len(response.Kvs))
+	}
+
+	// Moving onto a prefix that already holds keys leaves everything as is.
+	kv.Put(ctx, "/c/other", "x")
+	_, diags = applyTestResource(test, r, state, map[string]interface{}{
+		"prefix": "/c/",
+		"values": map[string]interface{}{"name": "passbase", "db/host": "localhost"},
+	}, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "already holds 1 keys") {
+		test.Fatalf("expected the move onto a used prefix to fail, got %v", diags)
+	}
+	assertKeyValue(test, kv, "/b/name", "passbase")
+	if response, _ := kv.Get(ctx, "/c/", clientv3.WithPrefix()); len(response.Kvs) != 1 {
+		test.Fatalf("expected the destination to be left untouched, got %d keys", len(response.Kvs))
+	}
+}
+
+func TestPrefixResourceImport(test *testing.T) {
+	pageSize := prefixPageSize
+	prefixPageSize = 3
+	defer func() { prefixPageSize = pageSize }()
+
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	for i := 0; i < 10; i++ {
+		kv.Put(ctx, fmt.Sprintf("/app/key%d", i), fmt.Sprintf("value%d", i))
+	}
+	// Neither the prefix key nor keys of a sibling prefix are imported.
+	kv.Put(ctx, "/app/", "root")
+	kv.Put(ctx, "/apps/other", "other")
+
+	r := PrefixResource()
+	d := r.Data(&terraform.InstanceState{ID: "prefix:/app/"})
+	imported, err := r.Importer.StateContext(ctx, d, client)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if len(imported) != 1 {
+		test.Fatalf("expected a single imported resource, got %d", len(imported))
+	}
+
+	state, diags := r.RefreshWithoutUpgrade(ctx, imported[0].State(), client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.ID != "/app/" || state.Attributes["prefix"] != "/app/" || state.Attributes["values.%"] != "10" {
+		test.Fatalf("expected the 10 keys below /app/ to be imported, got %v", state.Attributes)
+	}
+	for i := 0; i < 10; i++ {
+		if value := state.Attributes[fmt.Sprintf("values.key%d", i)]; value != fmt.Sprintf("value%d", i) {
+			test.Fatalf("expected key%d to be imported, got %q", i, value)
+		}
+	}
+
+	for _, id := range []string{"prefix:", "", "prefix:/missing/"} {
+		if _, err := r.Importer.StateContext(ctx, r.Data(&terraform.InstanceState{ID: id}), client); err == nil {
+			test.Fatalf("%q: expected the import to fail", id)
+		}
+	}
+}
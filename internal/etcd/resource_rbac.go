@@ -8,6 +8,9 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
@@ -19,7 +22,7 @@ func RoleResource() *schema.Resource {
 
 		CreateContext: RoleResourceCreate,
 		ReadContext:   RoleResourceRead,
-		//UpdateContext: resourceUpdate,
+		UpdateContext: RoleResourceUpdate,
 		DeleteContext: RoleResourceDelete,
 
 		Schema: map[string]*schema.Schema{
@@ -28,53 +31,170 @@ func RoleResource() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
+			"permission": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"range_end": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+							Description: "End of the key range, empty for a single key.",
+						},
+						"permission_type": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"read", "write", "readwrite"}, true),
+							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+								return strings.EqualFold(old, new)
+							},
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+func expandRolePermissionBlocks(blocks []interface{}) map[rolePermission]clientv3.PermissionType {
+	perms := map[rolePermission]clientv3.PermissionType{}
+	for _, raw := range blocks {
+		perm := raw.(map[string]interface{})
+		permType, _ := clientv3.StrToPermissionType(strings.ToUpper(perm["permission_type"].(string)))
+		perms[rolePermission{key: perm["key"].(string), rangeEnd: perm["range_end"].(string)}] = permType
+	}
+	return perms
+}
+
+// flattenRolePermissionBlocks keeps the order of the blocks already in state,
+// so that etcd returning permissions sorted by key does not show up as a diff.
+// Permissions granted outside of Terraform are appended.
+func flattenRolePermissionBlocks(previous []interface{}, granted []*authpb.Permission) []interface{} {
+	remaining := map[rolePermission]clientv3.PermissionType{}
+	order := []rolePermission{}
+	for _, perm := range granted {
+		id := rolePermission{key: string(perm.Key), rangeEnd: string(perm.RangeEnd)}
+		remaining[id] = clientv3.PermissionType(perm.PermType)
+		order = append(order, id)
+	}
+
+	blocks := []interface{}{}
+	add := func(id rolePermission) {
+		permType, ok := remaining[id]
+		if !ok {
+			return
+		}
+		delete(remaining, id)
+		blocks = append(blocks, map[string]interface{}{
+			"key":             id.key,
+			"range_end":       id.rangeEnd,
+			"permission_type": strings.ToLower(authpb.Permission_Type(permType).String()),
+		})
+	}
+	for _, raw := range previous {
+		perm := raw.(map[string]interface{})
+		add(rolePermission{key: perm["key"].(string), rangeEnd: perm["range_end"].(string)})
+	}
+	for _, id := range order {
+		add(id)
+	}
+	return blocks
+}
+
 func RoleResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
 
 	roleName := d.Get("name").(string)
 
 	roleName = strings.ToLower(roleName)
 
+	logf(ctx, "DEBUG", "adding role %q", roleName)
 	_, err := client.RoleAdd(ctx, roleName)
 	if err != nil {
-		diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
-	d.Set("name", roleName)
 	d.SetId(roleName)
-	//d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
-	return nil
+
+	for perm, permType := range expandRolePermissionBlocks(d.Get("permission").([]interface{})) {
+		if _, err := client.RoleGrantPermission(ctx, roleName, perm.key, perm.rangeEnd, permType); err != nil {
+			return classifyEtcdError(err)
+		}
+	}
+	return RoleResourceRead(ctx, d, meta)
 }
 
 func RoleResourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
 
 	roleName := d.Get("name").(string)
 	roleName = strings.ToLower(roleName)
 
-	_, err := client.RoleGet(ctx, roleName)
+	resp, err := client.RoleGet(ctx, roleName)
+	if err == rpctypes.ErrRoleNotFound {
+		logf(ctx, "DEBUG", "role %q was deleted, removing it from state", roleName)
+		d.SetId("")
+		return nil
+	}
 	if err != nil {
-		diag.FromErr(err)
+		return classifyEtcdError(err)
+	}
+	if err := d.Set("name", roleName); err != nil {
+		return diag.FromErr(err)
+	}
+	// A role without permission blocks leaves its permissions to
+	// etcd_grant_role_permission or etcd_role_permissions.
+	if previous := d.Get("permission").([]interface{}); len(previous) > 0 {
+		if err := d.Set("permission", flattenRolePermissionBlocks(previous, resp.Perm)); err != nil {
+			return diag.FromErr(err)
+		}
 	}
-	//if err := d.Set("permissions", resp.Perm); err != nil {
-	//	diag.FromErr(err)
-	//}
 	return nil
 }
 
+func RoleResourceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	old, new := d.GetChange("permission")
+	desired := expandRolePermissionBlocks(new.([]interface{}))
+	if len(desired) == 0 {
+		// Removing the last block hands the permissions over to the other
+		// resources, only those of the removed blocks are revoked.
+		logf(ctx, "DEBUG", "revoking the permission blocks removed from role %q", d.Id())
+		for perm := range expandRolePermissionBlocks(old.([]interface{})) {
+			_, err := client.RoleRevokePermission(ctx, d.Id(), perm.key, perm.rangeEnd)
+			if err != nil && err != rpctypes.ErrPermissionNotGranted {
+				return classifyEtcdError(err)
+			}
+		}
+		return RoleResourceRead(ctx, d, meta)
+	}
+	logf(ctx, "DEBUG", "setting the %d permissions of role %q", len(desired), d.Id())
+	if err := reconcileRolePermissions(ctx, client, d.Id(), desired); err != nil {
+		return classifyEtcdError(err)
+	}
+	return RoleResourceRead(ctx, d, meta)
+}
+
 func RoleResourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
 
 	roleName := d.Get("name").(string)
 
 	roleName = strings.ToLower(roleName)
 
+	logf(ctx, "DEBUG", "deleting role %q", roleName)
 	_, err := client.RoleDelete(ctx, roleName)
-	if err != nil {
-		diag.FromErr(err)
+	if err != nil && err != rpctypes.ErrRoleNotFound {
+		return classifyEtcdError(err)
 	}
 	d.SetId("")
 	return nil
@@ -118,7 +238,7 @@ func GrantUserRole(ctx context.Context, d *schema.ResourceData, meta interface{}
 
 	_, err := client.UserGrantRole(ctx, userName, roleName)
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	//d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
 	d.SetId(roleName)
@@ -131,7 +251,7 @@ func Users(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.D
 	users, err := client.UserList(ctx)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	userList := []string{}
 
@@ -154,7 +274,7 @@ func RevokeUserRole(ctx context.Context, d *schema.ResourceData, meta interface{
 
 	_, err := client.UserRevokeRole(ctx, userName, roleName)
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	d.SetId("")
 	return nil
@@ -211,7 +331,7 @@ func GrantRolePermission(ctx context.Context, d *schema.ResourceData, meta inter
 	_, err = client.RoleGrantPermission(ctx, roleName, key, rangePrefix, perm)
 
 	if err != nil {
-		diag.FromErr(err)
+		classifyEtcdError(err)
 	}
 	//d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
 	d.SetId(roleName)
@@ -234,7 +354,7 @@ func RevokeRolePermission(ctx context.Context, d *schema.ResourceData, meta inte
 	_, err := client.RoleRevokePermission(ctx, roleName, key, rangeEnd)
 
 	if err != nil {
-		diag.FromErr(err)
+		classifyEtcdError(err)
 	}
 	d.SetId("")
 	return nil
@@ -246,3 +366,163 @@ func NotImplemented(ctx context.Context, d *schema.ResourceData, meta interface{
 	return nil
 
 }
+
+func RolePermissionsResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Owns the complete set of permissions of a role, granting missing and revoking extra permissions on every apply.",
+
+		CreateContext: RolePermissionsCreate,
+		ReadContext:   RolePermissionsRead,
+		UpdateContext: RolePermissionsUpdate,
+		DeleteContext: RolePermissionsDelete,
+
+		Schema: map[string]*schema.Schema{
+			"role_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"permission": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"range_end": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+							Description: "End of the key range, empty for a single key.",
+						},
+						"permission": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"READ", "WRITE", "READWRITE"}, false),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// rolePermission identifies a permission by its key range, which is how etcd
+// matches permissions on grant and revoke.
+type rolePermission struct {
+	key      string
+	rangeEnd string
+}
+
+func expandRolePermissions(set *schema.Set) map[rolePermission]clientv3.PermissionType {
+	perms := map[rolePermission]clientv3.PermissionType{}
+	for _, raw := range set.List() {
+		perm := raw.(map[string]interface{})
+		permType, _ := clientv3.StrToPermissionType(perm["permission"].(string))
+		perms[rolePermission{key: perm["key"].(string), rangeEnd: perm["range_end"].(string)}] = permType
+	}
+	return perms
+}
+
+func reconcileRolePermissions(ctx context.Context, client *apiClient, roleName string, desired map[rolePermission]clientv3.PermissionType) error {
+	resp, err := client.RoleGet(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	for _, perm := range resp.Perm {
+		current := rolePermission{key: string(perm.Key), rangeEnd: string(perm.RangeEnd)}
+		permType, ok := desired[current]
+		if !ok {
+			if _, err := client.RoleRevokePermission(ctx, roleName, current.key, current.rangeEnd); err != nil {
+				return err
+			}
+			continue
+		}
+		if permType == clientv3.PermissionType(perm.PermType) {
+			delete(desired, current)
+		}
+	}
+
+	for perm, permType := range desired {
+		if _, err := client.RoleGrantPermission(ctx, roleName, perm.key, perm.rangeEnd, permType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RolePermissionsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	roleName := strings.ToLower(d.Get("role_name").(string))
+
+	desired := expandRolePermissions(d.Get("permission").(*schema.Set))
+	logf(ctx, "DEBUG", "setting the %d permissions of role %q", len(desired), roleName)
+	if err := reconcileRolePermissions(ctx, client, roleName, desired); err != nil {
+		return classifyEtcdError(err)
+	}
+	d.SetId(roleName)
+	return RolePermissionsRead(ctx, d, meta)
+}
+
+func RolePermissionsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	roleName := d.Id()
+
+	resp, err := client.RoleGet(ctx, roleName)
+	if err == rpctypes.ErrRoleNotFound {
+		logf(ctx, "DEBUG", "role %q was deleted, removing its permissions from state", roleName)
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	perms := []interface{}{}
+	for _, perm := range resp.Perm {
+		perms = append(perms, map[string]interface{}{
+			"key":        string(perm.Key),
+			"range_end":  string(perm.RangeEnd),
+			"permission": perm.PermType.String(),
+		})
+	}
+	if err := d.Set("role_name", roleName); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("permission", perms); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func RolePermissionsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	desired := expandRolePermissions(d.Get("permission").(*schema.Set))
+	logf(ctx, "DEBUG", "setting the %d permissions of role %q", len(desired), d.Id())
+	if err := reconcileRolePermissions(ctx, client, d.Id(), desired); err != nil {
+		return classifyEtcdError(err)
+	}
+	return RolePermissionsRead(ctx, d, meta)
+}
+
+func RolePermissionsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	logf(ctx, "DEBUG", "revoking every permission of role %q", d.Id())
+	err := reconcileRolePermissions(ctx, client, d.Id(), map[rolePermission]clientv3.PermissionType{})
+	if err != nil && err != rpctypes.ErrRoleNotFound {
+		return classifyEtcdError(err)
+	}
+	d.SetId("")
+	return nil
+}
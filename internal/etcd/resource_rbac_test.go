@@ -0,0 +1,172 @@
+package etcd
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func rolePermissionsOf(test *testing.T, auth *fakeAuth, roleName string) []string {
+	resp, err := auth.RoleGet(context.Background(), roleName)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	perms := []string{}
+	for _, perm := range resp.Perm {
+		perms = append(perms, perm.PermType.String()+" "+string(perm.Key)+"-"+string(perm.RangeEnd))
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+func TestRolePermissionsReconcile(test *testing.T) {
+	auth := newFakeAuth()
+	client := newFakeAuthClient(auth)
+	ctx := context.Background()
+
+	auth.RoleAdd(ctx, "developer")
+	auth.RoleGrantPermission(ctx, "developer", "/stale", "", 0)
+
+	d := schema.TestResourceDataRaw(test, RolePermissionsResource().Schema, map[string]interface{}{
+		"role_name": "developer",
+		"permission": []interface{}{
+			map[string]interface{}{"key": "/app/", "range_end": "/app0", "permission": "READ"},
+			map[string]interface{}{"key": "/app/config", "permission": "READWRITE"},
+		},
+	})
+	if diags := RolePermissionsCreate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	got := rolePermissionsOf(test, auth, "developer")
+	expected := []string{"READ /app/-/app0", "READWRITE /app/config-"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		test.Fatalf("expected %v, got %v", expected, got)
+	}
+	if d.Get("permission").(*schema.Set).Len() != 2 {
+		test.Fatalf("expected two permissions in state, got %v", d.Get("permission"))
+	}
+
+	d = schema.TestResourceDataRaw(test, RolePermissionsResource().Schema, map[string]interface{}{
+		"role_name": "developer",
+		"permission": []interface{}{
+			map[string]interface{}{"key": "/app/config", "permission": "WRITE"},
+		},
+	})
+	d.SetId("developer")
+	if diags := RolePermissionsUpdate(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	got = rolePermissionsOf(test, auth, "developer")
+	if len(got) != 1 || got[0] != "WRITE /app/config-" {
+		test.Fatalf("expected [WRITE /app/config-], got %v", got)
+	}
+
+	if diags := RolePermissionsDelete(ctx, d, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got = rolePermissionsOf(test, auth, "developer"); len(got) != 0 {
+		test.Fatalf("expected no permissions, got %v", got)
+	}
+}
+
+func TestRoleResourcePermissions(test *testing.T) {
+	auth := newFakeAuth()
+	client := newFakeAuthClient(auth)
+	ctx := context.Background()
+
+	r := RoleResource()
+	config := map[string]interface{}{
+		"name": "developer",
+		"permission": []interface{}{
+			map[string]interface{}{"key": "/z/", "range_end": "/z0", "permission_type": "read"},
+			map[string]interface{}{"key": "/a/config", "permission_type": "READWRITE"},
+		},
+	}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	got := rolePermissionsOf(test, auth, "developer")
+	expected := []string{"READ /z/-/z0", "READWRITE /a/config-"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		test.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	// Granted permissions are returned sorted, which must not cause a diff.
+	auth.mu.Lock()
+	perms := auth.roles["developer"].KeyPermission
+	perms[0], perms[1] = perms[1], perms[0]
+	auth.mu.Unlock()
+	state, diags = r.RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), client); err != nil || diff != nil && !diff.Empty() {
+		test.Fatalf("expected no diff, got %v (%v)", diff, err)
+	}
+
+	// A permission granted outside of Terraform is detected and revoked.
+	auth.RoleGrantPermission(ctx, "developer", "/extra", "", clientv3.PermissionType(clientv3.PermWrite))
+	state, diags = r.RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["permission.#"] != "3" || state.Attributes["permission.2.key"] != "/extra" {
+		test.Fatalf("expected the extra permission in state, got %v", state.Attributes)
+	}
+	if state, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got := rolePermissionsOf(test, auth, "developer"); len(got) != 2 {
+		test.Fatalf("expected the extra permission to be revoked, got %v", got)
+	}
+
+	if _, diags = applyTestResource(test, r, state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if _, err := auth.RoleGet(ctx, "developer"); err == nil {
+		test.Fatalf("expected the role to be deleted")
+	}
+}
+
+func TestRoleResourceWithoutBlocksKeepsGrants(test *testing.T) {
+	auth := newFakeAuth()
+	client := newFakeAuthClient(auth)
+	ctx := context.Background()
+
+	r := RoleResource()
+	config := map[string]interface{}{"name": "developer"}
+	state, diags := applyTestResource(test, r, nil, config, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if _, diags := applyTestResource(test, RolePermissionResource(), nil, map[string]interface{}{
+		"role_name":  "developer",
+		"key":        "/app/",
+		"range":      "/app/",
+		"permission": "WRITE",
+	}, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	// The permission of the grant resource is not the role's to manage.
+	state, diags = r.RefreshWithoutUpgrade(ctx, state, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), client); err != nil || diff != nil && !diff.Empty() {
+		test.Fatalf("expected no diff, got %v (%v)", diff, err)
+	}
+	if _, diags = applyTestResource(test, r, state, config, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if got := rolePermissionsOf(test, auth, "developer"); len(got) != 1 || got[0] != "WRITE /app/-/app0" {
+		test.Fatalf("expected the permission of the grant resource to be kept, got %v", got)
+	}
+}
@@ -0,0 +1,355 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func txnOpSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		ForceNew:    true,
+		Description: description,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"type": &schema.Schema{
+					Type:         schema.TypeString,
+					Required:     true,
+					ForceNew:     true,
+					ValidateFunc: validation.StringInSlice([]string{"put", "delete"}, false),
+				},
+				"key": &schema.Schema{
+					Type:     schema.TypeString,
+					Required: true,
+					ForceNew: true,
+				},
+				"value": &schema.Schema{
+					Type:        schema.TypeString,
+					Optional:    true,
+					ForceNew:    true,
+					Description: "Value written by a `put`.",
+				},
+			},
+		},
+	}
+}
+
+func TxnResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Runs a single etcd transaction on create: the `success` operations when every comparison holds, the `failure` operations otherwise.",
+
+		CreateContext: TxnResourceCreate,
+		ReadContext:   TxnResourceRead,
+		UpdateContext: TxnResourceUpdate,
+		DeleteContext: TxnResourceDelete,
+		CustomizeDiff: txnCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"compare": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+						"target": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.StringInSlice([]string{"value", "version", "create_revision", "mod_revision", "lease"}, false),
+						},
+						"result": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.StringInSlice([]string{"=", "!=", ">", "<"}, false),
+						},
+						"value": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "Value to compare against, an integer for every target but `value`.",
+						},
+					},
+				},
+			},
+			"success": txnOpSchema("Operations run when every comparison holds."),
+			"failure": txnOpSchema("Operations run when a comparison does not hold."),
+			"revert_on_destroy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Delete the keys written by the `success` puts on destroy.",
+			},
+			"restore_on_destroy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Record the values the keys written or deleted by the transaction held before it ran, and put them back on destroy.",
+			},
+			"previous_values": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Values the keys written or deleted by the transaction held before it ran, when `restore_on_destroy` is set. Keys the transaction created are not listed.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"value": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"succeeded": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"revision": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Cluster revision after the transaction.",
+			},
+		},
+	}
+}
+
+func expandTxnCompares(raw []interface{}) ([]clientv3.Cmp, error) {
+	cmps := []clientv3.Cmp{}
+	for i, item := range raw {
+		cmp, err := expandTxnCompare(i, item.(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		cmps = append(cmps, cmp)
+	}
+	return cmps, nil
+}
+
+func expandTxnCompare(i int, compare map[string]interface{}) (clientv3.Cmp, error) {
+	key := compare["key"].(string)
+	result := compare["result"].(string)
+	value := compare["value"].(string)
+
+	target := compare["target"].(string)
+	if target == "value" {
+		return clientv3.Compare(clientv3.Value(key), result, value), nil
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return clientv3.Cmp{}, fmt.Errorf("compare.%d: target %q needs an integer value, got %q", i, target, value)
+	}
+	var cmp clientv3.Cmp
+	switch target {
+	case "version":
+		cmp = clientv3.Version(key)
+	case "create_revision":
+		cmp = clientv3.CreateRevision(key)
+	case "mod_revision":
+		cmp = clientv3.ModRevision(key)
+	case "lease":
+		cmp = clientv3.LeaseValue(key)
+	default:
+		return clientv3.Cmp{}, fmt.Errorf("compare.%d: unsupported target %q", i, target)
+	}
+	return clientv3.Compare(cmp, result, number), nil
+}
+
+// expandTxnOps turns the blocks of name into operations, each with opts.
+func expandTxnOps(name string, raw []interface{}, opts ...clientv3.OpOption) ([]clientv3.Op, error) {
+	ops := []clientv3.Op{}
+	for i, item := range raw {
+		op, err := expandTxnOp(name, i, item.(map[string]interface{}), opts...)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func expandTxnOp(name string, i int, op map[string]interface{}, opts ...clientv3.OpOption) (clientv3.Op, error) {
+	key := op["key"].(string)
+	value := op["value"].(string)
+
+	switch op["type"].(string) {
+	case "put":
+		return clientv3.OpPut(key, value, opts...), nil
+	case "delete":
+		if value != "" {
+			return clientv3.Op{}, fmt.Errorf("%s.%d: a delete does not take a value", name, i)
+		}
+		return clientv3.OpDelete(key, opts...), nil
+	default:
+		return clientv3.Op{}, fmt.Errorf("%s.%d: unsupported operation %q", name, i, op["type"])
+	}
+}
+
+// txnCustomizeDiff fails the plan on blocks that cannot be turned into a
+// transaction, instead of failing halfway through the apply. Blocks with a
+// field only known once other resources are applied are checked on create.
+func txnCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	known := func(name string, i int, fields ...string) bool {
+		for _, field := range fields {
+			if !d.NewValueKnown(fmt.Sprintf("%s.%d.%s", name, i, field)) {
+				return false
+			}
+		}
+		return true
+	}
+	if d.NewValueKnown("compare") {
+		for i, item := range d.Get("compare").([]interface{}) {
+			if !known("compare", i, "target", "value") {
+				continue
+			}
+			if _, err := expandTxnCompare(i, item.(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range []string{"success", "failure"} {
+		if !d.NewValueKnown(name) {
+			continue
+		}
+		for i, item := range d.Get(name).([]interface{}) {
+			if !known(name, i, "type", "value") {
+				continue
+			}
+			if _, err := expandTxnOp(name, i, item.(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TxnResourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	cmps, err := expandTxnCompares(d.Get("compare").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// The previous values are returned by the operations themselves, so
+	// they are those of the same revision the transaction ran at.
+	var opts []clientv3.OpOption
+	restore := d.Get("restore_on_destroy").(bool)
+	if restore {
+		opts = append(opts, clientv3.WithPrevKV())
+	}
+	success, err := expandTxnOps("success", d.Get("success").([]interface{}), opts...)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	failure, err := expandTxnOps("failure", d.Get("failure").([]interface{}), opts...)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	logf(ctx, "DEBUG", "running transaction with %d comparisons", len(cmps))
+	response, err := client.Txn(ctx).If(cmps...).Then(success...).Else(failure...).Commit()
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+	logf(ctx, "DEBUG", "transaction succeeded: %v", response.Succeeded)
+
+	d.SetId(strconv.FormatInt(response.Header.Revision, 10))
+	if err := d.Set("succeeded", response.Succeeded); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("revision", int(response.Header.Revision)); err != nil {
+		return diag.FromErr(err)
+	}
+	if restore {
+		if err := d.Set("previous_values", txnPreviousValues(response)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+// txnPreviousValues lists the keys that held a value before the operations
+// of response overwrote or deleted them.
+func txnPreviousValues(response *clientv3.TxnResponse) []interface{} {
+	previous := []interface{}{}
+	for _, op := range response.Responses {
+		kvs := op.GetResponseDeleteRange().GetPrevKvs()
+		if kv := op.GetResponsePut().GetPrevKv(); kv != nil {
+			kvs = append(kvs, kv)
+		}
+		for _, kv := range kvs {
+			previous = append(previous, map[string]interface{}{
+				"key":   string(kv.Key),
+				"value": string(kv.Value),
+			})
+		}
+	}
+	return previous
+}
+
+// TxnResourceRead keeps the recorded outcome, a transaction that already ran
+// has nothing left to refresh.
+func TxnResourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// TxnResourceUpdate only records revert_on_destroy, every other change runs
+// a new transaction.
+func TxnResourceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return TxnResourceRead(ctx, d, meta)
+}
+
+func TxnResourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
+
+	// Keys with a previous value are put back, only those the transaction
+	// created are left to revert_on_destroy.
+	ops := []clientv3.Op{}
+	restored := map[string]bool{}
+	for _, item := range d.Get("previous_values").([]interface{}) {
+		previous := item.(map[string]interface{})
+		key := previous["key"].(string)
+		ops = append(ops, clientv3.OpPut(key, previous["value"].(string)))
+		restored[key] = true
+	}
+	if len(ops) > 0 {
+		logf(ctx, "DEBUG", "restoring %d previous values of the transaction", len(ops))
+	}
+	if d.Get("revert_on_destroy").(bool) && d.Get("succeeded").(bool) {
+		reverted := 0
+		for _, item := range d.Get("success").([]interface{}) {
+			op := item.(map[string]interface{})
+			if key := op["key"].(string); op["type"].(string) == "put" && !restored[key] {
+				ops = append(ops, clientv3.OpDelete(key))
+				reverted++
+			}
+		}
+		logf(ctx, "DEBUG", "reverting %d puts of the transaction", reverted)
+	}
+	if len(ops) > 0 {
+		if _, err := client.Txn(ctx).Then(ops...).Commit(); err != nil {
+			return classifyEtcdError(err)
+		}
+	}
+	d.SetId("")
+	return nil
+}
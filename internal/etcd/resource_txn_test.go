@@ -0,0 +1,171 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func txnConfig(expectedVersion string) map[string]interface{} {
+	return map[string]interface{}{
+		"compare": []interface{}{
+			map[string]interface{}{"key": "/app/lock", "target": "version", "result": "=", "value": expectedVersion},
+		},
+		"success": []interface{}{
+			map[string]interface{}{"type": "put", "key": "/app/lock", "value": "owner"},
+			map[string]interface{}{"type": "delete", "key": "/app/stale"},
+		},
+		"failure": []interface{}{
+			map[string]interface{}{"type": "put", "key": "/app/conflict", "value": "true"},
+		},
+		"revert_on_destroy": true,
+	}
+}
+
+func TestTxnResourceSuccess(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/app/stale", "x")
+
+	state, diags := applyTestResource(test, TxnResource(), nil, txnConfig("0"), client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["succeeded"] != "true" {
+		test.Fatalf("expected the transaction to succeed, got %v", state.Attributes)
+	}
+	assertKeyValue(test, kv, "/app/lock", "owner")
+	if response, _ := kv.Get(ctx, "/app/stale"); len(response.Kvs) != 0 {
+		test.Fatalf("expected /app/stale to be deleted")
+	}
+
+	if _, diags := applyTestResource(test, TxnResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if response, _ := kv.Get(ctx, "/app/lock"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the put to be reverted on destroy")
+	}
+}
+
+func TestTxnResourceFailure(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/app/lock", "someone else")
+
+	state, diags := applyTestResource(test, TxnResource(), nil, txnConfig("0"), client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if state.Attributes["succeeded"] != "false" {
+		test.Fatalf("expected the transaction to fail, got %v", state.Attributes)
+	}
+	assertKeyValue(test, kv, "/app/lock", "someone else")
+	assertKeyValue(test, kv, "/app/conflict", "true")
+
+	// Nothing was written by the success branch, nothing is reverted.
+	if _, diags := applyTestResource(test, TxnResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/lock", "someone else")
+}
+
+func TestTxnResourceRejectsInvalidBlocks(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	for name, config := range map[string]map[string]interface{}{
+		"non-integer version": txnConfig("latest"),
+		"delete with value": {
+			"success": []interface{}{
+				map[string]interface{}{"type": "delete", "key": "/app/lock", "value": "x"},
+			},
+		},
+	} {
+		_, err := TxnResource().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), client)
+		if err == nil || !strings.Contains(err.Error(), ".0:") {
+			test.Fatalf("%s: expected the plan to fail, got %v", name, err)
+		}
+	}
+}
+
+func TestTxnResourceUnknownValuesPlan(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	config := map[string]interface{}{
+		"compare": []interface{}{
+			// Like the mod_revision of a key changed in the same apply.
+			map[string]interface{}{"key": "/app/lock", "target": "mod_revision", "result": "=", "value": unknownValue},
+		},
+		"success": []interface{}{
+			map[string]interface{}{"type": unknownValue, "key": "/app/lock", "value": "owner"},
+		},
+	}
+	if _, err := TxnResource().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), client); err != nil {
+		test.Fatalf("expected unknown values to be checked on apply, got %v", err)
+	}
+}
+
+func TestTxnResourceRestoreOnDestroy(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/app/blue", "v1")
+	kv.Put(ctx, "/app/green", "v2")
+	kv.Put(ctx, "/app/stale", "x")
+
+	// Swap the values of two keys and delete a third.
+	state, diags := applyTestResource(test, TxnResource(), nil, map[string]interface{}{
+		"success": []interface{}{
+			map[string]interface{}{"type": "put", "key": "/app/blue", "value": "v2"},
+			map[string]interface{}{"type": "put", "key": "/app/green", "value": "v1"},
+			map[string]interface{}{"type": "put", "key": "/app/new", "value": "created"},
+			map[string]interface{}{"type": "delete", "key": "/app/stale"},
+		},
+		"restore_on_destroy": true,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/blue", "v2")
+	assertKeyValue(test, kv, "/app/green", "v1")
+	if state.Attributes["previous_values.#"] != "3" {
+		test.Fatalf("expected the previous values of the 3 existing keys, got %v", state.Attributes)
+	}
+
+	if _, diags := applyTestResource(test, TxnResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/blue", "v1")
+	assertKeyValue(test, kv, "/app/green", "v2")
+	assertKeyValue(test, kv, "/app/stale", "x")
+	// The key did not exist before, there is no value to restore.
+	assertKeyValue(test, kv, "/app/new", "created")
+}
+
+func TestTxnResourceRestoreAndRevertOnDestroy(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	client := newFakeClient(kv)
+	kv.Put(ctx, "/app/blue", "v1")
+
+	state, diags := applyTestResource(test, TxnResource(), nil, map[string]interface{}{
+		"success": []interface{}{
+			map[string]interface{}{"type": "put", "key": "/app/blue", "value": "v2"},
+			map[string]interface{}{"type": "put", "key": "/app/new", "value": "created"},
+		},
+		"restore_on_destroy": true,
+		"revert_on_destroy":  true,
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+
+	if _, diags := applyTestResource(test, TxnResource(), state, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	assertKeyValue(test, kv, "/app/blue", "v1")
+	if response, _ := kv.Get(ctx, "/app/new"); len(response.Kvs) != 0 {
+		test.Fatalf("expected the created key to be reverted on destroy")
+	}
+}
@@ -51,6 +51,7 @@ func UserResource() *schema.Resource {
 
 func UserResourceCreateUser(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
 
 	userName := d.Get("username").(string)
 	passWord := d.Get("password").(string)
@@ -61,9 +62,10 @@ func UserResourceCreateUser(ctx context.Context, d *schema.ResourceData, meta in
 		return diag.FromErr(errmsg)
 	}
 
+	logf(ctx, "DEBUG", "adding user %q", userName)
 	_, err := client.UserAdd(ctx, userName, passWord)
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	d.Set("username", userName)
 
@@ -74,13 +76,15 @@ func UserResourceCreateUser(ctx context.Context, d *schema.ResourceData, meta in
 
 func UserResourceDeleteUser(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
 
 	userName := d.Get("username").(string)
 	userName = strings.ToLower(userName)
 
+	logf(ctx, "DEBUG", "deleting user %q", userName)
 	_, err := client.UserDelete(ctx, userName)
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	d.SetId("")
 	return nil
@@ -88,6 +92,7 @@ func UserResourceDeleteUser(ctx context.Context, d *schema.ResourceData, meta in
 
 func UserResourceUpdateUser(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
 
 	userName := d.Get("username").(string)
 	passWord := d.Get("password").(string)
@@ -98,9 +103,10 @@ func UserResourceUpdateUser(ctx context.Context, d *schema.ResourceData, meta in
 		return diag.FromErr(errmsg)
 	}
 
+	logf(ctx, "DEBUG", "changing the password of user %q", userName)
 	_, err := client.UserChangePassword(ctx, userName, passWord)
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	d.Set("last_updated", time.Now().Format(time.RFC850))
 	//d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
@@ -110,6 +116,7 @@ func UserResourceUpdateUser(ctx context.Context, d *schema.ResourceData, meta in
 
 func UserResourceGetUser(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*apiClient)
+	ctx = client.startOperation(ctx)
 
 	userName := d.Get("username").(string)
 	userName = strings.ToLower(userName)
@@ -117,7 +124,7 @@ func UserResourceGetUser(ctx context.Context, d *schema.ResourceData, meta inter
 	resp, err := client.UserGet(ctx, userName)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return classifyEtcdError(err)
 	}
 	roles := []string{}
 
@@ -0,0 +1,125 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func UserRoleBindingResource() *schema.Resource {
+	return &schema.Resource{
+		Description: "Grants a single role to a user, so users and roles can be bound many-to-many without touching the user resource.",
+
+		CreateContext: UserRoleBindingCreate,
+		ReadContext:   UserRoleBindingRead,
+		DeleteContext: UserRoleBindingDelete,
+
+		// The import ID is user/role.
+		Importer: &schema.ResourceImporter{
+			StateContext: userRoleBindingImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"user": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"role": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func parseUserRoleBindingID(id string) (string, string, error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid user role binding ID %q, expected user/role", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+func userRoleBindingImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	user, role, err := parseUserRoleBindingID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	d.Set("user", user)
+	d.Set("role", role)
+	return []*schema.ResourceData{d}, nil
+}
+
+func UserRoleBindingCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	user := strings.ToLower(d.Get("user").(string))
+	role := strings.ToLower(d.Get("role").(string))
+
+	if _, err := client.UserGrantRole(ctx, user, role); err != nil {
+		return classifyEtcdError(err)
+	}
+	d.SetId(user + "/" + role)
+	return UserRoleBindingRead(ctx, d, meta)
+}
+
+func UserRoleBindingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	user, role, err := parseUserRoleBindingID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	resp, err := client.UserGet(ctx, user)
+	if err == rpctypes.ErrUserNotFound {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return classifyEtcdError(err)
+	}
+
+	// The binding was revoked outside of Terraform, let it be granted again.
+	granted := false
+	for _, name := range resp.Roles {
+		if name == role {
+			granted = true
+			break
+		}
+	}
+	if !granted {
+		d.SetId("")
+		return nil
+	}
+
+	if err := d.Set("user", user); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("role", role); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func UserRoleBindingDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*apiClient)
+
+	user, role, err := parseUserRoleBindingID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err = client.UserRevokeRole(ctx, user, role)
+	if err != nil && err != rpctypes.ErrRoleNotGranted && err != rpctypes.ErrUserNotFound {
+		return classifyEtcdError(err)
+	}
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,50 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUserRoleBindingLifecycle(test *testing.T) {
+	auth := newFakeAuth()
+	client := newFakeAuthClient(auth)
+	ctx := context.Background()
+
+	auth.UserAdd(ctx, "alice", "secret")
+	auth.RoleAdd(ctx, "developer")
+	auth.RoleAdd(ctx, "operator")
+
+	r := UserRoleBindingResource()
+	developer, diags := applyTestResource(test, r, nil, map[string]interface{}{"user": "alice", "role": "developer"}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if _, diags := applyTestResource(test, r, nil, map[string]interface{}{"user": "alice", "role": "operator"}, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if developer.ID != "alice/developer" {
+		test.Fatalf("expected ID alice/developer, got %q", developer.ID)
+	}
+	if resp, _ := auth.UserGet(ctx, "alice"); len(resp.Roles) != 2 {
+		test.Fatalf("expected both roles to be granted, got %v", resp.Roles)
+	}
+
+	// Revoked outside of Terraform.
+	auth.UserRevokeRole(ctx, "alice", "developer")
+	refreshed, diags := r.RefreshWithoutUpgrade(ctx, developer, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if refreshed != nil {
+		test.Fatalf("expected the revoked binding to be removed from state, got %v", refreshed)
+	}
+
+	// Destroying a binding that is already gone succeeds and leaves the
+	// other binding alone.
+	if _, diags := applyTestResource(test, r, developer, nil, client); diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if resp, _ := auth.UserGet(ctx, "alice"); len(resp.Roles) != 1 || resp.Roles[0] != "operator" {
+		test.Fatalf("expected only operator to remain, got %v", resp.Roles)
+	}
+}
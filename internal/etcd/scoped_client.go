@@ -0,0 +1,105 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	etcd "go.etcd.io/etcd/client/v3"
+)
+
+// scopedClients are clients for the endpoints a resource overrides, sharing
+// the TLS and authentication settings of the provider. One client is kept
+// per set of endpoints for the lifetime of the provider.
+type scopedClients struct {
+	mu      sync.Mutex
+	clients map[string]*apiClient
+
+	// dial creates a client for endpoints with the provider settings, living
+	// in ctx and checking the cluster ID of every response with guard when
+	// not nil.
+	dial func(ctx context.Context, endpoints []string, guard *clusterIDGuard) (*etcd.Client, error)
+}
+
+// forEndpoints returns the client of c for endpoints, c itself when none are
+// given.
+func (c *apiClient) forEndpoints(ctx context.Context, endpoints []string) (*apiClient, error) {
+	if len(endpoints) == 0 || c.scoped == nil {
+		return c, nil
+	}
+
+	key := strings.Join(endpoints, ",")
+	c.scoped.mu.Lock()
+	defer c.scoped.mu.Unlock()
+	if client, ok := c.scoped.clients[key]; ok {
+		return client, nil
+	}
+
+	// The endpoints may belong to another cluster than those of c, so
+	// verify_cluster_id records the ID they answer with separately.
+	var guard *clusterIDGuard
+	if c.clusterGuard != nil {
+		guard = &clusterIDGuard{}
+	}
+	logf(ctx, "DEBUG", "connecting to endpoints %s", key)
+	cli, err := c.scoped.dial(clientContext(ctx), endpoints, guard)
+	if err != nil {
+		return nil, err
+	}
+	client := &apiClient{
+		Client:               cli,
+		endpoints:            endpoints,
+		namespace:            c.namespace,
+		propagateOperationID: c.propagateOperationID,
+		keepAlives:           newKeepAliveManager(clientContext(ctx), cli),
+		requestTimeout:       c.requestTimeout,
+		clusterGuard:         guard,
+		keyValidation:        c.keyValidation,
+		maxValueBytes:        c.maxValueBytes,
+		parallelism:          c.parallelism,
+		onExternalDelete:     c.onExternalDelete,
+	}
+	if c.scoped.clients == nil {
+		c.scoped.clients = map[string]*apiClient{}
+	}
+	c.scoped.clients[key] = client
+	return client, nil
+}
+
+// dialEndpoints returns a client of its own for endpoints, bound to ctx
+// rather than kept for the lifetime of the provider, for a one-off request.
+// Dialing does not wait for the other clients being dialed, and takes at most
+// as long as ctx allows. release closes the client.
+func (c *apiClient) dialEndpoints(ctx context.Context, endpoints []string) (client *apiClient, release func(), err error) {
+	if c.scoped == nil {
+		return c, func() {}, nil
+	}
+	cli, err := c.scoped.dial(ctx, endpoints, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	client = &apiClient{
+		Client:               cli,
+		endpoints:            endpoints,
+		namespace:            c.namespace,
+		propagateOperationID: c.propagateOperationID,
+		requestTimeout:       c.requestTimeout,
+	}
+	return client, func() { cli.Close() }, nil
+}
+
+// resourceClient returns the client for the endpoints argument of d, the
+// provider client when it is not set.
+func resourceClient(ctx context.Context, d *schema.ResourceData, meta interface{}) (*apiClient, diag.Diagnostics) {
+	endpoints := []string{}
+	for _, endpoint := range d.Get("endpoints").([]interface{}) {
+		endpoints = append(endpoints, endpoint.(string))
+	}
+	client, err := meta.(*apiClient).forEndpoints(ctx, endpoints)
+	if err != nil {
+		return nil, diag.Errorf("unable to connect to endpoints %s: %v", strings.Join(endpoints, ","), err)
+	}
+	return client, nil
+}
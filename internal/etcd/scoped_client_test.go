@@ -0,0 +1,112 @@
+package etcd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestKvResourceEndpointsOverride(test *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	other := newFakeKV()
+	client := newFakeClient(kv)
+	dials := 0
+	client.scoped = &scopedClients{
+		dial: func(ctx context.Context, endpoints []string, guard *clusterIDGuard) (*clientv3.Client, error) {
+			dials++
+			return &clientv3.Client{KV: other}, nil
+		},
+	}
+
+	for _, key := range []string{"/app/a", "/app/b"} {
+		state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+			"key":       key,
+			"value":     "federated",
+			"endpoints": []interface{}{"etcd-eu:2379"},
+		}, client)
+		if diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+		if state, diags = KvResource().RefreshWithoutUpgrade(ctx, state, client); diags.HasError() || state == nil {
+			test.Fatalf("expected the key to be read from the overridden endpoints, got %v (%v)", state, diags)
+		}
+		assertKeyValue(test, other, key, "federated")
+	}
+	if response, _ := kv.Get(ctx, "/app/", clientv3.WithPrefix()); len(response.Kvs) != 0 {
+		test.Fatalf("expected nothing to be written through the provider endpoints")
+	}
+	if dials != 1 {
+		test.Fatalf("expected a single client for the endpoints, got %d", dials)
+	}
+}
+
+func TestConfigureScopedClient(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":    []interface{}{"127.0.0.1:0"},
+		"check_health": false,
+	})
+	meta, diags := configure(context.Background(), d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	scoped, err := client.forEndpoints(context.Background(), []string{"127.0.0.2:0", "127.0.0.3:0"})
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	defer scoped.Close()
+	if !reflect.DeepEqual(scoped.Endpoints(), []string{"127.0.0.2:0", "127.0.0.3:0"}) {
+		test.Fatalf("expected the overridden endpoints, got %v", scoped.Endpoints())
+	}
+	if again, _ := client.forEndpoints(context.Background(), []string{"127.0.0.2:0", "127.0.0.3:0"}); again != scoped {
+		test.Fatalf("expected the client to be cached")
+	}
+	if self, _ := client.forEndpoints(context.Background(), nil); self != client {
+		test.Fatalf("expected the provider client without endpoints")
+	}
+}
+
+func TestScopedClientVerifiesItsOwnClusterID(test *testing.T) {
+	ctx := context.Background()
+	endpoint, _ := serveCluster(test, 0xa1)
+	other, _ := serveCluster(test, 0xb2)
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":         []interface{}{endpoint},
+		"check_health":      false,
+		"verify_cluster_id": true,
+	})
+	meta, diags := configure(ctx, d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+	if _, err := client.Get(ctx, "/app/name"); err != nil {
+		test.Fatalf("err: %s", err)
+	}
+
+	// The overridden endpoints belong to another cluster, which is not a
+	// cluster change.
+	_, diags = applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":       "/app/name",
+		"value":     "passbase",
+		"endpoints": []interface{}{other},
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("expected the write through the overridden endpoints to succeed, got %v", diags)
+	}
+	scoped, _ := client.forEndpoints(ctx, []string{other})
+	if scoped.clusterGuard == nil || scoped.clusterGuard.clusterID() != 0xb2 {
+		test.Fatalf("expected the scoped client to record cluster b2, got %v", scoped.clusterGuard)
+	}
+	if client.clusterGuard.clusterID() != 0xa1 {
+		test.Fatalf("expected the provider client to keep cluster a1, got %x", client.clusterGuard.clusterID())
+	}
+}
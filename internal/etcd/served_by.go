@@ -0,0 +1,51 @@
+package etcd
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+type servedByKey struct{}
+
+// servedBy records the address of the endpoint that answered the RPCs of a
+// context, to tell which member of a balanced connection handled a write.
+type servedBy struct {
+	mu   sync.Mutex
+	addr string
+}
+
+// withServedBy returns a context whose RPCs record the endpoint serving
+// them in the returned servedBy.
+func withServedBy(ctx context.Context) (context.Context, *servedBy) {
+	served := &servedBy{}
+	return context.WithValue(ctx, servedByKey{}, served), served
+}
+
+// endpoint returns the address of the endpoint that answered the last
+// successful RPC, or an empty string when none went over gRPC.
+func (s *servedBy) endpoint() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// servedByInterceptor asks gRPC for the peer of the RPCs made with a context
+// from withServedBy. The peer is only known once the call returns, so it is
+// requested with a call option rather than read from the context.
+func servedByInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	served, ok := ctx.Value(servedByKey{}).(*servedBy)
+	if !ok {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	var p peer.Peer
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+	if err == nil && p.Addr != nil {
+		served.mu.Lock()
+		served.addr = p.Addr.String()
+		served.mu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package etcd
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"google.golang.org/grpc"
+)
+
+// writtenKVServer commits every transaction and answers every Range with
+// the key holding the value written by the test.
+type writtenKVServer struct {
+	pb.UnimplementedKVServer
+}
+
+func (*writtenKVServer) Txn(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	return &pb.TxnResponse{
+		Header:    &pb.ResponseHeader{Revision: 2},
+		Succeeded: true,
+		Responses: []*pb.ResponseOp{{Response: &pb.ResponseOp_ResponsePut{ResponsePut: &pb.PutResponse{}}}},
+	}, nil
+}
+
+func (*writtenKVServer) Range(ctx context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	return &pb.RangeResponse{
+		Header: &pb.ResponseHeader{Revision: 2},
+		Kvs:    []*mvccpb.KeyValue{{Key: req.Key, Value: []byte("passbase"), CreateRevision: 2, ModRevision: 2, Version: 1}},
+		Count:  1,
+	}, nil
+}
+
+func TestKvResourceServedBy(test *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterKVServer(server, &writtenKVServer{})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":    []interface{}{listener.Addr().String()},
+		"check_health": false,
+	})
+	meta, diags := configure(context.Background(), d)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	client := meta.(*apiClient)
+	defer client.Close()
+
+	state, diags := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "passbase",
+	}, client)
+	if diags.HasError() {
+		test.Fatalf("err: %v", diags)
+	}
+	if servedBy := state.Attributes["served_by"]; servedBy != listener.Addr().String() {
+		test.Fatalf("expected the key to be served by %s, got %q", listener.Addr(), servedBy)
+	}
+}
+
+func TestKvResourceServedByUnknownOnUpdate(test *testing.T) {
+	kv := newFakeKV()
+	state, _ := applyTestResource(test, KvResource(), nil, map[string]interface{}{
+		"key":   "/app/name",
+		"value": "passbase",
+	}, newFakeClient(kv))
+
+	diff, err := KvResource().Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key":   "/app/name",
+		"value": "awesome",
+	}), newFakeClient(kv))
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if attribute, ok := diff.Attributes["served_by"]; !ok || !attribute.NewComputed {
+		test.Fatalf("expected served_by to be unknown until the update is applied, got %#v", attribute)
+	}
+}
@@ -0,0 +1,85 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type operationFunc func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q: %v", k, err)}
+	}
+	return nil, nil
+}
+
+func validateNonNegativeDuration(v interface{}, k string) ([]string, []error) {
+	duration, err := time.ParseDuration(v.(string))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%q: %v", k, err)}
+	}
+	if duration < 0 {
+		return nil, []error{fmt.Errorf("%q must not be negative, got %s", k, duration)}
+	}
+	return nil, nil
+}
+
+// withRequestTimeout bounds ctx by the provider request_timeout, so a wedged
+// endpoint fails the operation instead of hanging the apply.
+func (c *apiClient) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// withRequestTimeout runs f under the provider request_timeout and reports
+// running out of time as a timeout instead of the raw context error. The
+// operation is registered in flight, so the client is not closed under it.
+func withRequestTimeout(f operationFunc) operationFunc {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		client := meta.(*apiClient)
+		if !client.beginOperation() {
+			return diag.Errorf("the provider is shutting down, its etcd client is closed")
+		}
+		defer client.endOperation()
+
+		ctx, cancel := client.withRequestTimeout(ctx)
+		defer cancel()
+
+		diags := f(ctx, d, meta)
+		if diags.HasError() && ctx.Err() == context.DeadlineExceeded {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "etcd request timed out",
+				Detail:   fmt.Sprintf("The operation did not complete within the provider request_timeout of %s. Check that the endpoints are reachable and healthy, or raise request_timeout.", client.requestTimeout),
+			}}
+		}
+		return diags
+	}
+}
+
+// applyRequestTimeout wraps the CRUD functions of r with withRequestTimeout.
+func applyRequestTimeout(r *schema.Resource) *schema.Resource {
+	if f := withRequestTimeout(operationFunc(r.CreateContext)); f != nil {
+		r.CreateContext = schema.CreateContextFunc(f)
+	}
+	if f := withRequestTimeout(operationFunc(r.ReadContext)); f != nil {
+		r.ReadContext = schema.ReadContextFunc(f)
+	}
+	if f := withRequestTimeout(operationFunc(r.UpdateContext)); f != nil {
+		r.UpdateContext = schema.UpdateContextFunc(f)
+	}
+	if f := withRequestTimeout(operationFunc(r.DeleteContext)); f != nil {
+		r.DeleteContext = schema.DeleteContextFunc(f)
+	}
+	return r
+}
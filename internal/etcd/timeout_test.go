@@ -0,0 +1,148 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// wedgedKV is a fakeKV whose reads hang until the request is abandoned, like
+// an endpoint that accepted the connection but stopped answering.
+type wedgedKV struct {
+	*fakeKV
+}
+
+func (w wedgedKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (w wedgedKV) Txn(ctx context.Context) clientv3.Txn {
+	return wedgedTxn{w.fakeKV.Txn(ctx), ctx}
+}
+
+// wedgedTxn is a transaction whose commit hangs until it is abandoned.
+type wedgedTxn struct {
+	clientv3.Txn
+	ctx context.Context
+}
+
+func (t wedgedTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	t.Txn = t.Txn.If(cs...)
+	return t
+}
+
+func (t wedgedTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.Txn = t.Txn.Then(ops...)
+	return t
+}
+
+func (t wedgedTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	t.Txn = t.Txn.Else(ops...)
+	return t
+}
+
+func (t wedgedTxn) Commit() (*clientv3.TxnResponse, error) {
+	<-t.ctx.Done()
+	return nil, t.ctx.Err()
+}
+
+func TestRequestTimeoutReportsTimeout(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.KV = wedgedKV{client.KV.(*fakeKV)}
+	client.requestTimeout = 50 * time.Millisecond
+
+	r := applyRequestTimeout(KeyValueDataSource())
+	d := schema.TestResourceDataRaw(test, r.Schema, map[string]interface{}{
+		"key": "/app/name",
+	})
+
+	done := make(chan diag.Diagnostics)
+	go func() {
+		done <- r.ReadContext(context.Background(), d, client)
+	}()
+
+	select {
+	case diags := <-done:
+		if !diags.HasError() || !strings.Contains(diags[0].Summary, "timed out") || !strings.Contains(diags[0].Detail, "50ms") {
+			test.Fatalf("expected a timeout diagnostic, got %v", diags)
+		}
+	case <-time.After(5 * time.Second):
+		test.Fatalf("read did not honour the request timeout")
+	}
+}
+
+func TestKvResourceRequestTimeout(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.KV = wedgedKV{client.KV.(*fakeKV)}
+	client.requestTimeout = 50 * time.Millisecond
+
+	// The resource as registered by the provider, with its timeouts applied.
+	r := New().ResourcesMap["etcd_key_value"]
+	for name, operation := range map[string]operationFunc{
+		"read":   operationFunc(r.ReadContext),
+		"delete": operationFunc(r.DeleteContext),
+	} {
+		d := schema.TestResourceDataRaw(test, r.Schema, map[string]interface{}{
+			"key":   "/app/name",
+			"value": "passbase",
+		})
+		d.SetId("/app/name")
+
+		done := make(chan diag.Diagnostics)
+		go func() {
+			done <- operation(context.Background(), d, client)
+		}()
+
+		select {
+		case diags := <-done:
+			if !diags.HasError() || !strings.Contains(diags[0].Summary, "timed out") {
+				test.Fatalf("%s: expected a timeout diagnostic, got %v", name, diags)
+			}
+		case <-time.After(5 * time.Second):
+			test.Fatalf("%s did not honour the request timeout", name)
+		}
+	}
+}
+
+func TestRequestTimeoutKeepsOtherErrors(test *testing.T) {
+	client := newFakeClient(newFakeKV())
+	client.requestTimeout = time.Second
+
+	r := applyRequestTimeout(KeyValueDataSource())
+	d := schema.TestResourceDataRaw(test, r.Schema, map[string]interface{}{
+		"key": "/app/missing",
+	})
+	diags := r.ReadContext(context.Background(), d, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "does not exist") {
+		test.Fatalf("expected the missing key error, got %v", diags)
+	}
+}
+
+func TestValidateNonNegativeDuration(test *testing.T) {
+	for value, valid := range map[string]bool{
+		"0s":  true,
+		"5m":  true,
+		"-1s": false,
+		"abc": false,
+	} {
+		_, errs := validateNonNegativeDuration(value, "auto_sync_interval")
+		if valid != (len(errs) == 0) {
+			test.Fatalf("%q: expected valid=%v, got %v", value, valid, errs)
+		}
+	}
+}
+
+func TestProviderTimeoutsRejectNegativeDurations(test *testing.T) {
+	provider := New()
+	for _, name := range []string{"dial_timeout", "request_timeout", "wait_timeout"} {
+		if _, errs := provider.Schema[name].ValidateFunc("-1s", name); len(errs) == 0 {
+			test.Fatalf("%s: expected a negative duration to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package etcd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// buildTLSConfig returns the TLS configuration of the client, or nil when no
+// TLS setting is configured and the connection stays in plaintext.
+func buildTLSConfig(d *schema.ResourceData) (*tls.Config, error) {
+	certFile := d.Get("cert_file").(string)
+	keyFile := d.Get("key_file").(string)
+	caFile := d.Get("ca_file").(string)
+	insecureSkipVerify := d.Get("insecure_skip_verify").(bool)
+	serverName := d.Get("tls_server_name").(string)
+
+	if certFile == "" && keyFile == "" && caFile == "" && !insecureSkipVerify {
+		if serverName != "" {
+			return nil, fmt.Errorf("tls_server_name is only used to verify TLS connections, set ca_file or cert_file and key_file to connect over TLS")
+		}
+		return nil, nil
+	}
+
+	// Without a server name, the host of the dialed endpoint is verified.
+	config := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify,
+		ServerName:         serverName,
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("cert_file and key_file must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the CA certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
@@ -0,0 +1,226 @@
+package etcd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"google.golang.org/grpc"
+	grpccredentials "google.golang.org/grpc/credentials"
+)
+
+type testCertificates struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	caPool   *x509.CertPool
+	server   tls.Certificate
+}
+
+func writePEM(test *testing.T, path, kind string, der []byte) {
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+		test.Fatalf("err: %s", err)
+	}
+}
+
+// newTestCertificates writes a CA and a client certificate signed by it to a
+// temporary directory, and returns a server certificate for serverName.
+func newTestCertificates(test *testing.T, serverName string) testCertificates {
+	dir := test.TempDir()
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "etcd-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			test.Fatalf("err: %s", err)
+		}
+		return der, key
+	}
+
+	certs := testCertificates{
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "client.pem"),
+		keyFile:  filepath.Join(dir, "client-key.pem"),
+		caPool:   x509.NewCertPool(),
+	}
+	certs.caPool.AddCert(caCert)
+	writePEM(test, certs.caFile, "CERTIFICATE", caDER)
+
+	clientDER, clientKey := issue(2, "terraform", x509.ExtKeyUsageClientAuth)
+	writePEM(test, certs.certFile, "CERTIFICATE", clientDER)
+	keyDER, _ := x509.MarshalECPrivateKey(clientKey)
+	writePEM(test, certs.keyFile, "EC PRIVATE KEY", keyDER)
+
+	serverDER, serverKey := issue(3, serverName, x509.ExtKeyUsageServerAuth)
+	certs.server = tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+	return certs
+}
+
+// serveTLS accepts mutual TLS connections until the test ends and returns
+// the listening address.
+func serveTLS(test *testing.T, certs testCertificates) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{certs.server},
+		ClientCAs:    certs.caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	test.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestBuildTLSConfig(test *testing.T) {
+	certs := newTestCertificates(test, "localhost")
+	addr := serveTLS(test, certs)
+
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints": []interface{}{addr},
+		"cert_file": certs.certFile,
+		"key_file":  certs.keyFile,
+		"ca_file":   certs.caFile,
+	})
+	config, err := buildTLSConfig(d)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+
+	config.ServerName = "localhost"
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, config)
+	if err != nil {
+		test.Fatalf("expected a mutual TLS handshake to succeed, got %s", err)
+	}
+	conn.Close()
+}
+
+func TestBuildTLSConfigFromEnvironment(test *testing.T) {
+	certs := newTestCertificates(test, "localhost")
+	os.Setenv("ETCDCTL_CERT", certs.certFile)
+	os.Setenv("ETCDCTL_KEY", certs.keyFile)
+	os.Setenv("ETCDCTL_CACERT", "/does/not/exist")
+	defer os.Unsetenv("ETCDCTL_CERT")
+	defer os.Unsetenv("ETCDCTL_KEY")
+	defer os.Unsetenv("ETCDCTL_CACERT")
+
+	// The explicit ca_file takes precedence over ETCDCTL_CACERT.
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints": []interface{}{"localhost:2379"},
+		"ca_file":   certs.caFile,
+	})
+	config, err := buildTLSConfig(d)
+	if err != nil {
+		test.Fatalf("err: %s", err)
+	}
+	if len(config.Certificates) != 1 || config.RootCAs == nil {
+		test.Fatalf("expected the certificate from the environment and the configured CA, got %+v", config)
+	}
+}
+
+func TestBuildTLSConfigPlaintext(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints": []interface{}{"localhost:2379"},
+	})
+	if config, err := buildTLSConfig(d); err != nil || config != nil {
+		test.Fatalf("expected no TLS configuration, got %v, %v", config, err)
+	}
+}
+
+func TestConfigureTLSServerName(test *testing.T) {
+	// The certificate names the load balancer, not the dialed address.
+	certs := newTestCertificates(test, "etcd.example.com")
+	endpoint := serveKV(test, grpc.Creds(grpccredentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{certs.server},
+		ClientCAs:    certs.caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+
+	for _, tc := range []struct {
+		serverName string
+		succeeds   bool
+	}{
+		{"", false},
+		{"etcd.example.com", true},
+	} {
+		d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+			"endpoints":       []interface{}{endpoint},
+			"check_health":    false,
+			"dial_timeout":    "1s",
+			"cert_file":       certs.certFile,
+			"key_file":        certs.keyFile,
+			"ca_file":         certs.caFile,
+			"tls_server_name": tc.serverName,
+		})
+		meta, diags := configure(context.Background(), d)
+		if diags.HasError() {
+			test.Fatalf("err: %v", diags)
+		}
+		client := meta.(*apiClient)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		_, err := client.Put(ctx, "/app/name", "passbase")
+		cancel()
+		client.Close()
+		if (err == nil) != tc.succeeds {
+			test.Fatalf("tls_server_name %q: expected success %v, got %v", tc.serverName, tc.succeeds, err)
+		}
+	}
+}
+
+func TestBuildTLSConfigServerNameRequiresTLS(test *testing.T) {
+	d := schema.TestResourceDataRaw(test, New().Schema, map[string]interface{}{
+		"endpoints":       []interface{}{"localhost:2379"},
+		"tls_server_name": "etcd.example.com",
+	})
+	if _, err := buildTLSConfig(d); err == nil {
+		test.Fatalf("expected tls_server_name without TLS to be rejected")
+	}
+}
@@ -0,0 +1,70 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// parseValueTemplate parses a value_template, calling lookup for each key
+// function the template runs.
+func parseValueTemplate(text string, lookup func(key string) (string, error)) (*template.Template, error) {
+	return template.New("value_template").
+		Funcs(template.FuncMap{"key": lookup}).
+		Option("missingkey=error").
+		Parse(text)
+}
+
+func validateValueTemplate(v interface{}, k string) ([]string, []error) {
+	noLookup := func(string) (string, error) { return "", nil }
+	if _, err := parseValueTemplate(v.(string), noLookup); err != nil {
+		return nil, []error{fmt.Errorf("%q: %v", k, err)}
+	}
+	return nil, nil
+}
+
+// renderValueTemplate renders a value_template, reading the keys it
+// references from etcd. A missing key fails the rendering instead of
+// rendering as empty.
+func renderValueTemplate(ctx context.Context, client *apiClient, text string) (string, error) {
+	lookup := func(key string) (string, error) {
+		response, err := client.Get(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if len(response.Kvs) == 0 {
+			return "", fmt.Errorf("key %q does not exist", key)
+		}
+		return string(response.Kvs[0].Value), nil
+	}
+	tmpl, err := parseValueTemplate(text, lookup)
+	if err != nil {
+		return "", err
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, nil); err != nil {
+		return "", fmt.Errorf("unable to render value_template: %v", err)
+	}
+	return rendered.String(), nil
+}
+
+// planValueTemplate renders a value_template at plan time, with the client
+// the resource writes the key with.
+func planValueTemplate(ctx context.Context, d *schema.ResourceDiff, meta interface{}, text string) (string, error) {
+	client, ok := meta.(*apiClient)
+	if !ok || !d.NewValueKnown("value_template") || !d.NewValueKnown("endpoints") {
+		return "", fmt.Errorf("value_template cannot be rendered at plan time")
+	}
+	endpoints := []string{}
+	for _, endpoint := range d.Get("endpoints").([]interface{}) {
+		endpoints = append(endpoints, endpoint.(string))
+	}
+	client, err := client.forEndpoints(ctx, endpoints)
+	if err != nil {
+		return "", err
+	}
+	return renderValueTemplate(ctx, client, text)
+}